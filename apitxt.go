@@ -0,0 +1,306 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github/urie96/go-list-export/pkg/export"
+)
+
+// writeAPITxt writes pkg in the format of the Go distribution's api/*.txt
+// files: one sorted line per declaration, struct field and interface
+// method, prefixed with the package path and, for symbols limited to some
+// platforms, the platform. newConfig type-checks the packages for this
+// format, so that the lines are rendered from go/types as the API checker
+// renders them. Packages that could not be type-checked get lines derived
+// from the signatures, without parameter names: untyped constants then
+// only get an ideal kind when their value is a literal, and interfaces
+// list their embedded interfaces rather than their methods.
+func writeAPITxt(w io.Writer, pkg *export.Package, header bool) {
+	path := apiImportPath(pkg)
+	lines := []string{}
+	for _, sym := range pkg.Symbols() {
+		prefixes := []string{"pkg " + path + ", "}
+		if len(sym.Platforms) > 0 {
+			prefixes = prefixes[:0]
+			for _, p := range sym.Platforms {
+				prefixes = append(prefixes, fmt.Sprintf("pkg %s (%s), ", path, strings.ReplaceAll(p, "/", "-")))
+			}
+		}
+		var decls []string
+		if tpkg := pkg.Types(); tpkg != nil {
+			decls = apiTypesDecls(tpkg, sym)
+		}
+		if decls == nil {
+			decls = apiDecls(sym)
+		}
+		if len(decls) > 0 && sym.Deprecated != "" {
+			// A line of its own, as api/go1.N.txt adds it to the
+			// declaration listed by an earlier release, so that
+			// deprecating a symbol only adds a line.
+			decls = append(decls, apiDeprecated(sym, decls[0]))
+		}
+		for _, decl := range decls {
+			for _, prefix := range prefixes {
+				lines = append(lines, prefix+decl)
+			}
+		}
+	}
+	sort.Strings(lines)
+	for _, line := range lines {
+		fmt.Fprintln(w, line)
+	}
+}
+
+// apiDeprecated returns the line marking sym deprecated, which names it
+// by its kind and name only, as in "func F //deprecated", with the
+// receiver of a method as decl, its first declaration, renders it:
+// "method (*T) M //deprecated".
+func apiDeprecated(sym *export.Symbol, decl string) string {
+	if sym.Kind == export.KindMethod {
+		recv, _, _ := strings.Cut(strings.TrimPrefix(decl, "method "), ") ")
+		return "method " + recv + ") " + sym.Name + " //deprecated"
+	}
+	return string(sym.Kind) + " " + sym.Name + " //deprecated"
+}
+
+// apiImportPath returns the import path of pkg for its apitxt lines. A
+// package listed by a directory, such as ./p, gets the path of its module
+// joined with its directory below the module root, so that the lines do
+// not depend on how the package was named on the command line.
+func apiImportPath(pkg *export.Package) string {
+	if !isLocalPath(pkg.Path) || pkg.Dir == "" {
+		return pkg.Path
+	}
+	root := moduleRoot(pkg.Dir)
+	if root == "" {
+		return pkg.Path
+	}
+	modPath := modulePath(filepath.Join(root, "go.mod"))
+	rel, err := filepath.Rel(root, pkg.Dir)
+	if modPath == "" || err != nil {
+		return pkg.Path
+	}
+	return joinImportPath(modPath, filepath.ToSlash(rel))
+}
+
+// apiDecls returns the api.txt declarations for sym, without the package
+// prefix.
+func apiDecls(sym *export.Symbol) []string {
+	switch sym.Kind {
+	case export.KindFunc:
+		return []string{singleLine(sym.Signature)}
+	case export.KindMethod:
+		return []string{"method " + singleLine(strings.TrimPrefix(sym.Signature, "func "))}
+	case export.KindVar:
+		decl, _, _ := strings.Cut(sym.Signature, " = ")
+		return []string{decl}
+	case export.KindConst:
+		decl, value, hasValue := strings.Cut(sym.Signature, " = ")
+		value = singleLine(value)
+		res := []string{}
+		if decl != "const "+sym.Name {
+			res = append(res, decl) // typed constant
+		} else if kind := idealKind(value); kind != "" {
+			res = append(res, decl+" "+kind)
+		}
+		if hasValue && idealKind(value) != "" {
+			res = append(res, "const "+sym.Name+" = "+value)
+		}
+		return res
+	}
+
+	// Types: structs and interfaces get a line per member.
+	prefix, typ := typeDeclHead(sym)
+	switch {
+	case strings.HasPrefix(typ, "struct {"):
+		res := []string{prefix + "struct"}
+		for _, member := range blockMembers(typ) {
+			member = stripTag(member)
+			names, ftyp := fieldNames(member)
+			if len(names) == 0 {
+				res = append(res, prefix+"struct, embedded "+member)
+				continue
+			}
+			for _, name := range names {
+				res = append(res, prefix+"struct, "+name+" "+ftyp)
+			}
+		}
+		return res
+	case strings.HasPrefix(typ, "interface {"):
+		methods := []string{}
+		res := []string{}
+		for _, member := range blockMembers(typ) {
+			if name, _, ok := strings.Cut(member, "("); ok && !strings.ContainsAny(name, " ~|*.[") {
+				methods = append(methods, name)
+				res = append(res, prefix+"interface, "+member)
+			} else {
+				res = append(res, prefix+"interface, embedded "+member)
+			}
+		}
+		if strings.Contains(typ, "// contains filtered or unexported methods") {
+			return append(res, prefix+"interface, unexported methods")
+		}
+		sort.Strings(methods)
+		head := prefix + "interface { " + strings.Join(methods, ", ") + " }"
+		if len(methods) == 0 {
+			head = prefix + "interface {}"
+		}
+		return append([]string{head}, res...)
+	}
+	return []string{singleLine(sym.Signature)}
+}
+
+// typeDeclHead splits the signature of a type into its head, "type T "
+// or with type parameters "type T[P any] ", and the type that follows.
+func typeDeclHead(sym *export.Symbol) (head, typ string) {
+	rest := strings.TrimPrefix(sym.Signature, "type "+sym.Name)
+	if strings.HasPrefix(rest, "[") {
+		depth := 0
+		for i, r := range rest {
+			switch r {
+			case '[':
+				depth++
+			case ']':
+				depth--
+			}
+			if depth == 0 {
+				rest = rest[i+1:]
+				break
+			}
+		}
+	}
+	head = strings.TrimSuffix(sym.Signature, rest) + " "
+	return head, strings.TrimPrefix(rest, " ")
+}
+
+// blockMembers returns the top-level members of a multi-line struct or
+// interface body, each collapsed onto one line. Comments are dropped.
+func blockMembers(body string) []string {
+	lines := strings.Split(body, "\n")
+	if len(lines) < 3 {
+		return nil
+	}
+	members := []string{}
+	depth := 0
+	var cur []string
+	for _, line := range lines[1 : len(lines)-1] {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "//") {
+			continue
+		}
+		cur = append(cur, line)
+		depth += strings.Count(line, "{") - strings.Count(line, "}")
+		if depth == 0 {
+			members = append(members, joinBlock(cur))
+			cur = nil
+		}
+	}
+	return members
+}
+
+// joinBlock joins the lines of a member with a nested body the way gofmt
+// writes one-line bodies: "struct{ A int; B string }".
+func joinBlock(lines []string) string {
+	if len(lines) == 1 {
+		return lines[0]
+	}
+	var b strings.Builder
+	for i, line := range lines {
+		switch {
+		case i == 0:
+			b.WriteString(strings.Replace(line, " {", "{", 1))
+		case i == len(lines)-1:
+			b.WriteString(" " + line)
+		default:
+			b.WriteString(" " + line)
+			if !strings.HasSuffix(line, "{") && !strings.HasPrefix(lines[i+1], "}") {
+				b.WriteString(";")
+			}
+		}
+	}
+	return b.String()
+}
+
+// singleLine collapses a multi-line signature onto one line.
+func singleLine(s string) string {
+	if !strings.Contains(s, "\n") {
+		return s
+	}
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(line)
+	}
+	return joinBlock(lines)
+}
+
+// fieldNames splits a struct field line such as "A, B int" into its names
+// and type. It returns no names for an embedded field.
+func fieldNames(field string) (names []string, typ string) {
+	rest := field
+	for {
+		i := 0
+		for i < len(rest) && (isIdentByte(rest[i]) || rest[i] == '_') {
+			i++
+		}
+		if i == 0 {
+			return nil, ""
+		}
+		names = append(names, rest[:i])
+		switch {
+		case strings.HasPrefix(rest[i:], ", "):
+			rest = rest[i+2:]
+		case strings.HasPrefix(rest[i:], " "):
+			return names, rest[i+1:]
+		default:
+			return nil, ""
+		}
+	}
+}
+
+// stripTag removes the struct tag from a field line.
+func stripTag(field string) string {
+	if i := strings.Index(field, " `"); i >= 0 && strings.HasSuffix(field, "`") {
+		return field[:i]
+	}
+	return field
+}
+
+// idealKind returns the ideal kind the API checker reports for an untyped
+// constant with the given value, or "" if value is not a (possibly
+// negated) literal.
+func idealKind(value string) string {
+	expr, err := parser.ParseExpr(value)
+	if err != nil {
+		return ""
+	}
+	if u, ok := expr.(*ast.UnaryExpr); ok && (u.Op == token.SUB || u.Op == token.ADD) {
+		expr = u.X
+	}
+	switch e := expr.(type) {
+	case *ast.Ident:
+		if e.Name == "true" || e.Name == "false" {
+			return "ideal-bool"
+		}
+	case *ast.BasicLit:
+		switch e.Kind {
+		case token.INT:
+			return "ideal-int"
+		case token.FLOAT:
+			return "ideal-float"
+		case token.IMAG:
+			return "ideal-complex"
+		case token.CHAR:
+			return "ideal-char"
+		case token.STRING:
+			return "ideal-string"
+		}
+	}
+	return ""
+}
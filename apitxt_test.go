@@ -0,0 +1,160 @@
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github/urie96/go-list-export/pkg/export"
+)
+
+const apiTxtSource = `package p
+
+import "io"
+
+const (
+	Big   = 1 << 70
+	Name  = "p"
+	Typed int = 3
+)
+
+var Reader io.Reader
+
+var Inferred = []byte("x")
+
+type Kind uint8
+
+type List[T any] struct {
+	Head  *T
+	items []T
+}
+
+func (l *List[T]) Push(v T) {}
+
+type Sealed interface {
+	io.Closer
+	Name() string
+	seal()
+}
+
+type ReadNamer interface {
+	io.Reader
+	Name() string
+}
+
+// Deprecated: use G.
+func F(r rune, args ...any) (n int, err error) { return }
+
+func G[K comparable, V any](m map[K]V) (keys []K) { return }
+`
+
+func TestWriteAPITxt(t *testing.T) {
+	cfg := &export.Config{OmitParamNames: true, Types: true}
+	pkg, err := cfg.ParseFS(fstest.MapFS{"p/p.go": {Data: []byte(apiTxtSource)}}, "p")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkg.Path = "example.com/p"
+	var buf bytes.Buffer
+	writeAPITxt(&buf, pkg, false)
+	want := []string{
+		"const Big = 1180591620717411303424",
+		"const Big ideal-int",
+		`const Name = "p"`,
+		"const Name ideal-string",
+		"const Typed = 3",
+		"const Typed int",
+		"func F //deprecated",
+		"func F(int32, ...interface{}) (int, error)",
+		"func G[$0 comparable, $1 interface{}](map[$0]$1) []$0",
+		"method (*List[$0]) Push($0)",
+		"type Kind uint8",
+		"type List[$0 interface{}] struct",
+		"type List[$0 interface{}] struct, Head *$0",
+		"type ReadNamer interface { Name, Read }",
+		"type ReadNamer interface, Name() string",
+		"type ReadNamer interface, Read([]uint8) (int, error)",
+		"type Sealed interface, Close() error",
+		"type Sealed interface, Name() string",
+		"type Sealed interface, unexported methods",
+		"var Inferred []uint8",
+		"var Reader io.Reader",
+	}
+	got := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(got) != len(want) {
+		t.Fatalf("writeAPITxt() wrote %d lines, want %d:\n%s", len(got), len(want), buf.String())
+	}
+	for i, line := range got {
+		if line != "pkg example.com/p, "+want[i] {
+			t.Errorf("line %d = %q, want %q", i, line, "pkg example.com/p, "+want[i])
+		}
+	}
+}
+
+func TestAPIDecls(t *testing.T) {
+	tests := []struct {
+		name string
+		sym  *export.Symbol
+		want []string
+	}{
+		{
+			name: "func",
+			sym:  &export.Symbol{Kind: export.KindFunc, Name: "F", Signature: "func F(int, ...string) (bool, error)"},
+			want: []string{"func F(int, ...string) (bool, error)"},
+		},
+		{
+			name: "method",
+			sym:  &export.Symbol{Kind: export.KindMethod, Name: "M", Receiver: "*T", Signature: "func (*T) M() error"},
+			want: []string{"method (*T) M() error"},
+		},
+		{
+			name: "var with value",
+			sym:  &export.Symbol{Kind: export.KindVar, Name: "V", Signature: "var V int = 1"},
+			want: []string{"var V int"},
+		},
+		{
+			name: "typed const",
+			sym:  &export.Symbol{Kind: export.KindConst, Name: "C", Signature: "const C Kind = 2"},
+			want: []string{"const C Kind", "const C = 2"},
+		},
+		{
+			name: "untyped string const",
+			sym:  &export.Symbol{Kind: export.KindConst, Name: "S", Signature: `const S = "s"`},
+			want: []string{"const S ideal-string", `const S = "s"`},
+		},
+		{
+			name: "struct",
+			sym:  &export.Symbol{Kind: export.KindType, Name: "S", Signature: "type S struct {\n\tio.Reader\n\tA, B int `json:\"a\"`\n\t// contains filtered or unexported fields\n}"},
+			want: []string{
+				"type S struct",
+				"type S struct, embedded io.Reader",
+				"type S struct, A int",
+				"type S struct, B int",
+			},
+		},
+		{
+			name: "interface",
+			sym:  &export.Symbol{Kind: export.KindType, Name: "I", Signature: "type I interface {\n\tM()\n\tL() int\n}"},
+			want: []string{"type I interface { L, M }", "type I interface, M()", "type I interface, L() int"},
+		},
+		{
+			name: "sealed interface",
+			sym:  &export.Symbol{Kind: export.KindType, Name: "I", Signature: "type I interface {\n\tM()\n\t// contains filtered or unexported methods\n}"},
+			want: []string{"type I interface, M()", "type I interface, unexported methods"},
+		},
+		{
+			name: "generic type",
+			sym:  &export.Symbol{Kind: export.KindType, Name: "L", Signature: "type L[T any] []T"},
+			want: []string{"type L[T any] []T"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := apiDecls(tt.sym); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("apiDecls() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
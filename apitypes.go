@@ -0,0 +1,332 @@
+package main
+
+import (
+	"fmt"
+	"go/types"
+	"sort"
+	"strings"
+
+	"github/urie96/go-list-export/pkg/export"
+)
+
+// apiTypeWriter renders the declarations of a type-checked package as the
+// API checker, cmd/api, does: type parameters are numbered $0, $1, ...,
+// any is written as interface{}, byte and rune as uint8 and int32, and
+// types of other packages are qualified by package name.
+type apiTypeWriter struct {
+	pkg *types.Package
+}
+
+// apiTypesDecls returns the api.txt declarations of sym, without the
+// package prefix, from the type-checked package tpkg, or nil if sym has
+// no object in it.
+func apiTypesDecls(tpkg *types.Package, sym *export.Symbol) []string {
+	w := apiTypeWriter{tpkg}
+	if sym.Kind == export.KindMethod {
+		m := apiMethod(tpkg, sym)
+		if m == nil {
+			return nil
+		}
+		sig := m.Type().(*types.Signature)
+		return []string{"method " + w.receiver(sig) + " " + m.Name() + w.signature(sig)}
+	}
+	switch obj := tpkg.Scope().Lookup(sym.Name).(type) {
+	case *types.Func:
+		return []string{"func " + obj.Name() + w.signature(obj.Type().(*types.Signature))}
+	case *types.Var:
+		return []string{"var " + obj.Name() + " " + w.typeString(obj.Type())}
+	case *types.Const:
+		decl := "const " + obj.Name() + " = " + obj.Val().String()
+		if exact := obj.Val().ExactString(); exact != obj.Val().String() {
+			decl += "  // " + exact
+		}
+		return []string{"const " + obj.Name() + " " + w.typeString(obj.Type()), decl}
+	case *types.TypeName:
+		return w.typeDecls(obj)
+	}
+	return nil
+}
+
+// apiMethod returns the method sym of tpkg, or nil.
+func apiMethod(tpkg *types.Package, sym *export.Symbol) *types.Func {
+	tn, _ := tpkg.Scope().Lookup(receiverType(sym)).(*types.TypeName)
+	if tn == nil {
+		return nil
+	}
+	named, _ := types.Unalias(tn.Type()).(*types.Named)
+	if named == nil {
+		return nil
+	}
+	for i := 0; i < named.NumMethods(); i++ {
+		if m := named.Method(i); m.Name() == sym.Name {
+			return m
+		}
+	}
+	return nil
+}
+
+// typeDecls returns the declarations of a type: one line for most types,
+// and for structs and interfaces a further line per exported field or
+// method.
+func (w apiTypeWriter) typeDecls(obj *types.TypeName) []string {
+	name := obj.Name()
+	if obj.IsAlias() {
+		return []string{"type " + name + " = " + w.typeString(obj.Type())}
+	}
+	named, ok := obj.Type().(*types.Named)
+	if !ok {
+		return nil
+	}
+	if tparams := named.TypeParams(); tparams.Len() > 0 {
+		var b strings.Builder
+		b.WriteString(name)
+		w.writeTypeParams(&b, tparams, true)
+		name = b.String()
+	}
+	switch u := named.Underlying().(type) {
+	case *types.Struct:
+		head := "type " + name + " struct"
+		res := []string{head}
+		for i := 0; i < u.NumFields(); i++ {
+			f := u.Field(i)
+			switch {
+			case !f.Exported():
+			case f.Anonymous():
+				res = append(res, head+", embedded "+w.typeString(f.Type()))
+			default:
+				res = append(res, head+", "+f.Name()+" "+w.typeString(f.Type()))
+			}
+		}
+		return res
+	case *types.Interface:
+		// The method set includes the methods of embedded interfaces.
+		head := "type " + name + " interface"
+		res := []string{}
+		methods := []string{}
+		complete := true
+		mset := types.NewMethodSet(u)
+		for i := 0; i < mset.Len(); i++ {
+			m := mset.At(i).Obj()
+			if !m.Exported() {
+				complete = false
+				continue
+			}
+			methods = append(methods, m.Name())
+			res = append(res, head+", "+m.Name()+w.signature(m.Type().(*types.Signature)))
+		}
+		if !complete {
+			// Only the package can implement the interface, so it may
+			// gain methods: the method list is left out.
+			return append(res, head+", unexported methods")
+		}
+		sort.Strings(methods)
+		if len(methods) == 0 {
+			return append([]string{head + " {}"}, res...)
+		}
+		return append([]string{head + " { " + strings.Join(methods, ", ") + " }"}, res...)
+	}
+	return []string{"type " + name + " " + w.typeString(named.Underlying())}
+}
+
+// receiver returns the receiver of a method signature in parentheses,
+// with the type parameters of a generic receiver type: "(*List[$0])".
+func (w apiTypeWriter) receiver(sig *types.Signature) string {
+	var b strings.Builder
+	b.WriteString("(")
+	recv := types.Unalias(sig.Recv().Type())
+	if p, ok := recv.(*types.Pointer); ok {
+		b.WriteString("*")
+		recv = types.Unalias(p.Elem())
+	}
+	if named, ok := recv.(*types.Named); ok {
+		b.WriteString(named.Obj().Name())
+	} else {
+		w.writeType(&b, recv)
+	}
+	if rtp := sig.RecvTypeParams(); rtp.Len() > 0 {
+		w.writeTypeParams(&b, rtp, false)
+	}
+	b.WriteString(")")
+	return b.String()
+}
+
+func (w apiTypeWriter) typeString(t types.Type) string {
+	var b strings.Builder
+	w.writeType(&b, t)
+	return b.String()
+}
+
+func (w apiTypeWriter) signature(sig *types.Signature) string {
+	var b strings.Builder
+	w.writeSignature(&b, sig)
+	return b.String()
+}
+
+func (w apiTypeWriter) writeTypeParams(b *strings.Builder, tparams *types.TypeParamList, withConstraints bool) {
+	b.WriteString("[")
+	for i := 0; i < tparams.Len(); i++ {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		tp := tparams.At(i)
+		w.writeType(b, tp)
+		if withConstraints {
+			b.WriteString(" ")
+			w.writeType(b, tp.Constraint())
+		}
+	}
+	b.WriteString("]")
+}
+
+func (w apiTypeWriter) writeSignature(b *strings.Builder, sig *types.Signature) {
+	if tparams := sig.TypeParams(); tparams.Len() > 0 {
+		w.writeTypeParams(b, tparams, true)
+	}
+	w.writeParams(b, sig.Params(), sig.Variadic())
+	switch res := sig.Results(); {
+	case res.Len() == 1:
+		b.WriteString(" ")
+		w.writeType(b, res.At(0).Type())
+	case res.Len() > 0:
+		b.WriteString(" ")
+		w.writeParams(b, res, false)
+	}
+}
+
+// writeParams writes the types of a parameter list, the last of them
+// with ... if variadic.
+func (w apiTypeWriter) writeParams(b *strings.Builder, t *types.Tuple, variadic bool) {
+	b.WriteString("(")
+	for i := 0; i < t.Len(); i++ {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		typ := t.At(i).Type()
+		if variadic && i == t.Len()-1 {
+			b.WriteString("...")
+			if s, ok := typ.(*types.Slice); ok {
+				typ = s.Elem()
+			}
+		}
+		w.writeType(b, typ)
+	}
+	b.WriteString(")")
+}
+
+func (w apiTypeWriter) writeType(b *strings.Builder, t types.Type) {
+	switch t := t.(type) {
+	case *types.Basic:
+		switch t.Kind() {
+		case types.UnsafePointer:
+			b.WriteString("unsafe.Pointer")
+		case types.UntypedBool:
+			b.WriteString("ideal-bool")
+		case types.UntypedInt:
+			b.WriteString("ideal-int")
+		case types.UntypedRune:
+			b.WriteString("ideal-rune")
+		case types.UntypedFloat:
+			b.WriteString("ideal-float")
+		case types.UntypedComplex:
+			b.WriteString("ideal-complex")
+		case types.UntypedString:
+			b.WriteString("ideal-string")
+		case types.Uint8:
+			b.WriteString("uint8") // also byte
+		case types.Int32:
+			b.WriteString("int32") // also rune
+		default:
+			b.WriteString(t.Name())
+		}
+	case *types.Alias:
+		w.writeType(b, types.Unalias(t))
+	case *types.Array:
+		fmt.Fprintf(b, "[%d]", t.Len())
+		w.writeType(b, t.Elem())
+	case *types.Slice:
+		b.WriteString("[]")
+		w.writeType(b, t.Elem())
+	case *types.Pointer:
+		b.WriteString("*")
+		w.writeType(b, t.Elem())
+	case *types.Map:
+		b.WriteString("map[")
+		w.writeType(b, t.Key())
+		b.WriteString("]")
+		w.writeType(b, t.Elem())
+	case *types.Chan:
+		switch t.Dir() {
+		case types.SendOnly:
+			b.WriteString("chan<- ")
+		case types.RecvOnly:
+			b.WriteString("<-chan ")
+		default:
+			b.WriteString("chan ")
+		}
+		w.writeType(b, t.Elem())
+	case *types.Signature:
+		b.WriteString("func")
+		w.writeSignature(b, t)
+	case *types.Struct:
+		b.WriteString("struct{")
+		for i := 0; i < t.NumFields(); i++ {
+			if i > 0 {
+				b.WriteString("; ")
+			}
+			f := t.Field(i)
+			if !f.Anonymous() {
+				b.WriteString(f.Name() + " ")
+			}
+			w.writeType(b, f.Type())
+			if tag := t.Tag(i); tag != "" {
+				fmt.Fprintf(b, " %q", tag)
+			}
+		}
+		b.WriteString("}")
+	case *types.Interface:
+		items := []string{}
+		for i := 0; i < t.NumEmbeddeds(); i++ {
+			items = append(items, w.typeString(t.EmbeddedType(i)))
+		}
+		for i := 0; i < t.NumExplicitMethods(); i++ {
+			m := t.ExplicitMethod(i)
+			items = append(items, m.Name()+w.signature(m.Type().(*types.Signature)))
+		}
+		if len(items) == 0 {
+			b.WriteString("interface{}")
+		} else {
+			b.WriteString("interface{ " + strings.Join(items, "; ") + " }")
+		}
+	case *types.Union:
+		for i := 0; i < t.Len(); i++ {
+			if i > 0 {
+				b.WriteString(" | ")
+			}
+			if t.Term(i).Tilde() {
+				b.WriteString("~")
+			}
+			w.writeType(b, t.Term(i).Type())
+		}
+	case *types.Named:
+		if pkg := t.Obj().Pkg(); pkg != nil && pkg != w.pkg {
+			b.WriteString(pkg.Name() + ".")
+		}
+		b.WriteString(t.Obj().Name())
+		if targs := t.TypeArgs(); targs.Len() > 0 {
+			b.WriteString("[")
+			for i := 0; i < targs.Len(); i++ {
+				if i > 0 {
+					b.WriteString(", ")
+				}
+				w.writeType(b, targs.At(i))
+			}
+			b.WriteString("]")
+		}
+	case *types.TypeParam:
+		fmt.Fprintf(b, "$%d", t.Index())
+	case *types.Tuple:
+		w.writeParams(b, t, false)
+	default:
+		b.WriteString(t.String())
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github/urie96/go-list-export/pkg/export"
+)
+
+// writeAsciiDoc writes a title per package and a section per file, like
+// writeMarkdown. Signatures go into [source,go] listing blocks and doc
+// comments become paragraphs between them; consecutive undocumented
+// symbols share one block.
+func writeAsciiDoc(w io.Writer, pkg *export.Package, header bool) {
+	fmt.Fprintf(w, "= package `%s`", pkg.Path)
+	if pkg.Version != "" {
+		fmt.Fprintf(w, " (%s)", pkg.Version)
+	}
+	fmt.Fprint(w, "\n\n")
+	if pkg.Module != "" {
+		fmt.Fprintf(w, "Module `%s`, read from `%s`.\n\n", pkg.Module, pkg.Dir)
+	}
+	if pkg.Doc != "" {
+		fmt.Fprintf(w, "%s\n\n", pkg.Doc)
+	}
+	for _, f := range pkg.Files {
+		if f.Name != "" { // not combined
+			fmt.Fprintf(w, "== %s%s\n\n", f.Name, fileNotes(f))
+		}
+		inBlock := false
+		for _, sym := range f.Symbols {
+			if sym.Doc != "" {
+				if inBlock {
+					fmt.Fprint(w, "----\n\n")
+					inBlock = false
+				}
+				fmt.Fprintf(w, "%s\n\n", sym.Doc)
+			}
+			if !inBlock {
+				fmt.Fprint(w, "[source,go]\n----\n")
+				inBlock = true
+			}
+			fmt.Fprintln(w, sym.Signature+annotation(sym))
+		}
+		if inBlock {
+			fmt.Fprint(w, "----\n\n")
+		}
+	}
+	if len(pkg.Examples) > 0 {
+		fmt.Fprint(w, "== Examples\n\n")
+		for _, ex := range pkg.Examples {
+			fmt.Fprintf(w, "* `%s` (%s)\n", ex.Name, exampleTarget(ex))
+		}
+		fmt.Fprintln(w, "")
+	}
+	if len(pkg.Generate) > 0 {
+		fmt.Fprint(w, "== go:generate\n\n")
+		for _, d := range pkg.Generate {
+			fmt.Fprintf(w, "* `%s` (%s:%d)\n", d.Command, d.File, d.Line)
+		}
+		fmt.Fprintln(w, "")
+	}
+}
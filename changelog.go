@@ -0,0 +1,160 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github/urie96/go-list-export/pkg/export"
+)
+
+// versionChanges is the API change of a package from one version to the
+// next, an entry of the changelog command.
+type versionChanges struct {
+	Version  string           `json:"version"`
+	Previous string           `json:"previous"`
+	Impact   export.Impact    `json:"impact"`
+	Changes  []*export.Change `json:"changes"`
+}
+
+// runChangelog implements `go-list-export changelog pkg [FROM..TO]`. It
+// diffs every consecutive pair of the tagged versions of pkg's module in
+// the range, as found in GOMODCACHE and, with --proxy, on GOPROXY, and
+// prints the changes of each version, newest first, as markdown. Either
+// bound may be left out; prereleases are skipped unless they are a bound.
+func runChangelog(cwd string, args []string) {
+	if len(args) < 1 || len(args) > 2 {
+		usageError("changelog: usage: changelog pkg [FROM..TO]")
+	}
+	importPath := args[0]
+	var from, to string
+	if len(args) == 2 {
+		var ok bool
+		if from, to, ok = strings.Cut(args[1], ".."); !ok {
+			usageError("changelog: version range %s is not of the form FROM..TO", args[1])
+		}
+	}
+
+	versions := []string{}
+	for _, v := range availableVersions(importPath) {
+		if from != "" && compareSemver(v, from) < 0 || to != "" && compareSemver(v, to) > 0 {
+			continue
+		}
+		if isPrerelease(v) && v != from && v != to {
+			continue
+		}
+		versions = append(versions, v)
+	}
+	if len(versions) < 2 {
+		fatal(&notFoundError{arg: importPath, reason: fmt.Sprintf("%d version(s) in range, need two to compare; run with --proxy to list those on GOPROXY", len(versions))})
+	}
+
+	cfg := newConfig()
+	cfg.OmitParamNames = true
+	entries := []versionChanges{}
+	prev, err := loadPackage(cfg, importPath+"@"+versions[0], cwd)
+	if prev == nil {
+		fatal(err)
+	}
+	for _, v := range versions[1:] {
+		pkg, err := loadPackage(cfg, importPath+"@"+v, cwd)
+		if pkg == nil {
+			report(err)
+			continue
+		}
+		changes := export.Diff(prev, pkg)
+		entries = append(entries, versionChanges{Version: v, Previous: prev.Version, Impact: export.ImpactOf(changes), Changes: changes})
+		prev = pkg
+	}
+	for i, j := 0, len(entries)-1; i < j; i, j = i+1, j-1 {
+		entries[i], entries[j] = entries[j], entries[i]
+	}
+
+	if *outputFormat == "json" {
+		encodeJSON(entries)
+		return
+	}
+	fmt.Printf("# Changelog of `%s`\n", importPath)
+	for _, e := range entries {
+		fmt.Printf("\n## %s\n\n", e.Version)
+		if len(e.Changes) == 0 {
+			fmt.Printf("No API changes since %s.\n", e.Previous)
+			continue
+		}
+		fmt.Printf("Changes since %s, %s (%s).\n", e.Previous, e.Impact, e.Impact.Bump())
+		writeChangeSection("Added", "go", e.Changes, export.Added)
+		writeChangeSection("Removed", "go", e.Changes, export.Removed)
+		writeChangeSection("Changed", "diff", e.Changes, export.Changed)
+	}
+}
+
+// writeChangeSection prints the changes of one kind under a heading, in a
+// code block of the given language.
+func writeChangeSection(heading, lang string, changes []*export.Change, kind export.ChangeKind) {
+	var b strings.Builder
+	for _, c := range changes {
+		if c.Kind != kind {
+			continue
+		}
+		switch kind {
+		case export.Added:
+			fmt.Fprintln(&b, c.New.Signature)
+		case export.Removed:
+			fmt.Fprintln(&b, c.Old.Signature)
+		case export.Changed:
+			fmt.Fprintf(&b, "- %s\n+ %s\n", indentContinuation(c.Old.Signature), indentContinuation(c.New.Signature))
+		}
+	}
+	if b.Len() > 0 {
+		fmt.Printf("\n### %s\n\n```%s\n%s```\n", heading, lang, b.String())
+	}
+}
+
+// availableVersions returns the versions of the module holding importPath
+// found in GOMODCACHE, as extracted trees or in the download cache, and
+// with --proxy on GOPROXY, oldest first. The module is the longest prefix
+// of importPath with any version.
+func availableVersions(importPath string) []string {
+	for modPath := importPath; modPath != "." && modPath != "/"; modPath = path.Dir(modPath) {
+		seen := map[string]bool{}
+		versions := []string{}
+		add := func(v string) {
+			if _, ok := parseSemver(v); ok && !seen[v] {
+				seen[v] = true
+				versions = append(versions, v)
+			}
+		}
+		parent := filepath.Join(goModCache(), filepath.FromSlash(escapeModulePath(path.Dir(modPath))))
+		if list, err := os.ReadDir(parent); err == nil {
+			prefix := escapeModulePath(path.Base(modPath)) + "@"
+			for _, d := range list {
+				if v, ok := strings.CutPrefix(d.Name(), prefix); ok && d.IsDir() {
+					add(unescapeModulePath(v))
+				}
+			}
+		}
+		vdir := filepath.Join(goModCache(), "cache", "download", filepath.FromSlash(escapeModulePath(modPath)), "@v")
+		if list, err := os.ReadDir(vdir); err == nil {
+			for _, f := range list {
+				if v, ok := strings.CutSuffix(f.Name(), ".zip"); ok {
+					add(unescapeModulePath(v))
+				}
+			}
+		}
+		if *useProxy {
+			if data, err := proxyGet(modPath, "@v/list"); err == nil {
+				for _, v := range strings.Fields(string(data)) {
+					add(v)
+				}
+			}
+		}
+		if len(versions) > 0 {
+			sort.Slice(versions, func(i, j int) bool { return compareSemver(versions[i], versions[j]) < 0 })
+			return versions
+		}
+	}
+	return nil
+}
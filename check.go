@@ -0,0 +1,100 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// issueSuffix matches the issue references ending lines of the Go
+// distribution's api/*.txt files.
+var issueSuffix = regexp.MustCompile(` #\d+$`)
+
+// runCheck compares the API of the arguments, in apitxt form, with the
+// baseline file given by --baseline, which is typically created with
+// `go-list-export --format apitxt ./... > api.txt`. It lists the baseline
+// lines that no longer hold, meaning removed or changed declarations, and
+// the new lines, and exits with exitDrift if any line no longer holds.
+// Additions alone pass the check.
+func runCheck(cwd string, args []string) {
+	if *baseline == "" {
+		usageError("check: --baseline is required")
+	}
+	want, err := readAPILines(*baseline)
+	if err != nil {
+		fatal(err)
+	}
+
+	format := *outputFormat
+	*outputFormat = "apitxt"
+	var buf bytes.Buffer
+	stdout = &buf
+	listArgs(newConfig(), cwd, args)
+	stdout = os.Stdout
+	*outputFormat = format
+	if exitCode != exitOK {
+		os.Exit(exitCode)
+	}
+	have := map[string]bool{}
+	for _, line := range strings.Split(buf.String(), "\n") {
+		if line != "" {
+			have[line] = true
+		}
+	}
+
+	removed, added := []string{}, []string{}
+	for line := range want {
+		if !have[line] {
+			removed = append(removed, line)
+		}
+	}
+	for line := range have {
+		if !want[line] {
+			added = append(added, line)
+		}
+	}
+	sort.Strings(removed)
+	sort.Strings(added)
+
+	if *outputFormat == "json" {
+		encodeJSON(struct {
+			Removed []string `json:"removed"`
+			Added   []string `json:"added"`
+		}{removed, added})
+	} else {
+		fmt.Printf("// check against %s: %d removed or changed, %d added\n", *baseline, len(removed), len(added))
+		for _, line := range removed {
+			fmt.Println("- " + line)
+		}
+		for _, line := range added {
+			fmt.Println("+ " + line)
+		}
+	}
+	if len(removed) > 0 && exitCode == exitOK {
+		exitCode = exitDrift
+	}
+}
+
+// readAPILines reads the lines of an apitxt file. Blank lines and issue
+// references are ignored.
+func readAPILines(name string) (map[string]bool, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	lines := map[string]bool{}
+	sc := bufio.NewScanner(f)
+	sc.Buffer(nil, 1<<20)
+	for sc.Scan() {
+		line := issueSuffix.ReplaceAllString(strings.TrimSpace(sc.Text()), "")
+		if line != "" {
+			lines[line] = true
+		}
+	}
+	return lines, sc.Err()
+}
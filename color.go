@@ -0,0 +1,133 @@
+package main
+
+import (
+	"bytes"
+	"go/scanner"
+	"go/token"
+	"io"
+	"os"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ANSI escapes used by colorWriter.
+const (
+	ansiReset   = "\x1b[0m"
+	ansiBold    = "\x1b[1m"
+	ansiRed     = "\x1b[31m"
+	ansiGreen   = "\x1b[32m"
+	ansiMagenta = "\x1b[35m"
+	ansiCyan    = "\x1b[36m"
+	ansiGray    = "\x1b[90m"
+)
+
+// predeclaredTypes are colored as type names.
+var predeclaredTypes = map[string]bool{
+	"any": true, "bool": true, "byte": true, "comparable": true, "complex64": true, "complex128": true,
+	"error": true, "float32": true, "float64": true, "int": true, "int8": true, "int16": true,
+	"int32": true, "int64": true, "rune": true, "string": true, "uint": true, "uint8": true,
+	"uint16": true, "uint32": true, "uint64": true, "uintptr": true,
+}
+
+// useColor reports whether --color asks for colored output: always, or
+// with auto when stdout is a terminal and NO_COLOR is not set.
+func useColor() bool {
+	switch *colorMode {
+	case "always":
+		return true
+	case "never":
+		return false
+	}
+	if os.Getenv("NO_COLOR") != "" || *outDir != "" {
+		return false
+	}
+	fi, err := os.Stdout.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+// colorWriter highlights the Go declarations written to it line by line:
+// keywords, declared names, type names, literals and comments, with
+// deprecation markers standing out.
+type colorWriter struct {
+	w   io.Writer
+	buf []byte
+}
+
+func (cw *colorWriter) Write(p []byte) (int, error) {
+	cw.buf = append(cw.buf, p...)
+	for {
+		i := bytes.IndexByte(cw.buf, '\n')
+		if i < 0 {
+			return len(p), nil
+		}
+		if _, err := io.WriteString(cw.w, colorLine(string(cw.buf[:i]))+"\n"); err != nil {
+			return 0, err
+		}
+		cw.buf = cw.buf[i+1:]
+	}
+}
+
+// colorLine highlights one line of Go source.
+func colorLine(line string) string {
+	src := []byte(line)
+	fset := token.NewFileSet()
+	file := fset.AddFile("", -1, len(src))
+	var s scanner.Scanner
+	s.Init(file, src, func(token.Position, string) {}, scanner.ScanComments)
+
+	var b strings.Builder
+	last := 0
+	expectName, inRecv := false, false
+	for {
+		pos, tok, lit := s.Scan()
+		if tok == token.EOF {
+			break
+		}
+		if tok == token.SEMICOLON && lit == "\n" {
+			continue // inserted automatically
+		}
+		off := file.Offset(pos)
+		text := lit
+		if text == "" {
+			text = tok.String()
+		}
+		if off < last || off+len(text) > len(line) {
+			continue
+		}
+		b.WriteString(line[last:off])
+		last = off + len(text)
+
+		color := ""
+		switch {
+		case tok == token.COMMENT:
+			text = strings.ReplaceAll(text, "Deprecated", ansiRed+"Deprecated"+ansiGray)
+			color = ansiGray
+		case tok.IsKeyword():
+			color = ansiMagenta
+			expectName = tok == token.FUNC || tok == token.TYPE || tok == token.VAR || tok == token.CONST
+		case tok == token.LPAREN && expectName:
+			inRecv, expectName = true, false // a receiver, or a declaration group
+		case tok == token.RPAREN && inRecv:
+			inRecv, expectName = false, true
+		case tok == token.IDENT && expectName:
+			color, expectName = ansiBold, false
+		case tok == token.IDENT && (predeclaredTypes[lit] || isExportedName(lit)):
+			color = ansiCyan
+		case tok.IsLiteral() && tok != token.IDENT:
+			color = ansiGreen
+		}
+		if color == "" {
+			b.WriteString(text)
+		} else {
+			b.WriteString(color + text + ansiReset)
+		}
+	}
+	b.WriteString(line[last:])
+	return b.String()
+}
+
+func isExportedName(name string) bool {
+	r, _ := utf8.DecodeRuneInString(name)
+	return unicode.IsUpper(r)
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// completionScripts are the shell completion scripts printed by the
+// completion command. They ask the hidden __complete command for the
+// candidates of the word being completed, leaving local paths to the
+// shell's own file completion.
+var completionScripts = map[string]string{
+	"bash": `# bash completion for go-list-export; load with:
+#   source <(go-list-export completion bash)
+_go_list_export() {
+	local cur="${COMP_WORDS[COMP_CWORD]}" prev="${COMP_WORDS[COMP_CWORD-1]}"
+	COMPREPLY=($(compgen -W "$(go-list-export __complete "$COMP_CWORD" "$prev" "$cur" 2>/dev/null)" -- "$cur"))
+}
+complete -o default -F _go_list_export go-list-export
+`,
+	"zsh": `#compdef go-list-export
+# zsh completion for go-list-export; load with:
+#   source <(go-list-export completion zsh)
+_go_list_export() {
+	local -a candidates
+	candidates=(${(f)"$(go-list-export __complete $((CURRENT-1)) "${words[CURRENT-1]}" "${words[CURRENT]}" 2>/dev/null)"})
+	compadd -a candidates
+	_files
+}
+compdef _go_list_export go-list-export
+`,
+	"fish": `# fish completion for go-list-export; load with:
+#   go-list-export completion fish | source
+function __go_list_export_complete
+	set -l tokens (commandline -opc)
+	go-list-export __complete (count $tokens) $tokens[-1] (commandline -ct) 2>/dev/null
+end
+complete -c go-list-export -a '(__go_list_export_complete)'
+`,
+}
+
+// runCompletion prints the completion script of the shell given as
+// argument.
+func runCompletion(cwd string, args []string) {
+	if len(args) != 1 || completionScripts[args[0]] == "" {
+		usageError("completion: usage: completion bash|zsh|fish")
+	}
+	fmt.Print(completionScripts[args[0]])
+}
+
+// flagValues lists the values of the flags that take one of a few.
+var flagValues = map[string]func() []string{
+	"format": func() []string {
+		names := []string{}
+		for name := range formatters {
+			names = append(names, name)
+		}
+		return names
+	},
+	"sort":  func() []string { return []string{"source", "position", "name", "kind"} },
+	"color": func() []string { return []string{"auto", "always", "never"} },
+	"cgo":   func() []string { return []string{"include", "skip"} },
+}
+
+// runComplete prints the completion candidates for the word cur at
+// position index of the command line, following the word prev, one per
+// line. It is called by the completion scripts as
+// "__complete index prev cur", before the global flags are parsed.
+func runComplete(args []string) {
+	if len(args) != 3 {
+		os.Exit(exitUsage)
+	}
+	index, _ := strconv.Atoi(args[0])
+	prev, cur := args[1], args[2]
+	candidates := []string{}
+	switch name := strings.TrimLeft(prev, "-"); {
+	case strings.HasPrefix(prev, "-") && flagValues[name] != nil:
+		candidates = flagValues[name]()
+	case strings.HasPrefix(cur, "-"):
+		flag.VisitAll(func(f *flag.Flag) {
+			candidates = append(candidates, "--"+f.Name)
+		})
+	default:
+		if index == 1 {
+			for name := range commands {
+				candidates = append(candidates, name)
+			}
+		}
+		if !isLocalPath(cur) {
+			candidates = append(candidates, completeImportPath(cur)...)
+		}
+	}
+	sort.Strings(candidates)
+	for i, c := range candidates {
+		if strings.HasPrefix(c, cur) && (i == 0 || c != candidates[i-1]) {
+			fmt.Println(c)
+		}
+	}
+}
+
+// completeImportPath returns the import paths completing cur: standard
+// library packages, the modules required by the current module and those
+// in GOMODCACHE, and the package directories of a module once its path
+// is complete. Directories are offered one level at a time.
+func completeImportPath(cur string) []string {
+	res := []string{}
+	if isStdImportPath(cur) && goRoot() != "" {
+		res = append(res, completeDirs(filepath.Join(goRoot(), "src"), "", cur)...)
+	}
+	cwd, _ := os.Getwd()
+	modules := map[string]string{} // module path -> directory, if cached
+	if root := moduleRoot(cwd); root != "" {
+		for _, req := range requiredModules(filepath.Join(root, "go.mod")) {
+			modules[req] = ""
+		}
+	}
+	for _, mod := range cachedModules(false) {
+		modules[mod.Path] = mod.Dir
+	}
+	for modPath, dir := range modules {
+		switch {
+		case strings.HasPrefix(modPath, cur):
+			res = append(res, modPath)
+		case dir != "" && strings.HasPrefix(cur, modPath+"/"):
+			res = append(res, completeDirs(dir, modPath, cur)...)
+		}
+	}
+	return res
+}
+
+// completeDirs returns the import paths of the subdirectories of the
+// directory cur is in, within the tree rooted at dir whose import path is
+// root ("" for GOROOT/src). Directories the go command ignores are left
+// out.
+func completeDirs(dir, root, cur string) []string {
+	rel := strings.TrimPrefix(strings.TrimPrefix(cur, root), "/")
+	parent := ""
+	if i := strings.LastIndex(rel, "/"); i >= 0 {
+		parent = rel[:i]
+	}
+	entries, err := os.ReadDir(filepath.Join(dir, filepath.FromSlash(parent)))
+	if err != nil {
+		return nil
+	}
+	res := []string{}
+	for _, e := range entries {
+		name := e.Name()
+		if !e.IsDir() || name == "testdata" || name == "vendor" || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") {
+			continue
+		}
+		path := strings.TrimPrefix(parent+"/"+name, "/")
+		if root != "" {
+			path = root + "/" + path
+		}
+		res = append(res, path)
+	}
+	return res
+}
+
+// requiredModules returns the module paths required by a go.mod file.
+func requiredModules(gomod string) []string {
+	data, err := os.ReadFile(gomod)
+	if err != nil {
+		return nil
+	}
+	res := []string{}
+	inRequire := false
+	for _, line := range strings.Split(string(data), "\n") {
+		if i := strings.Index(line, "//"); i >= 0 {
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+		switch {
+		case len(fields) == 0:
+		case inRequire && fields[0] == ")":
+			inRequire = false
+		case fields[0] == "require" && len(fields) > 1 && fields[1] == "(":
+			inRequire = true
+		case fields[0] == "require" && len(fields) > 2:
+			res = append(res, unquoteModField(fields[1]))
+		case inRequire && len(fields) >= 2:
+			res = append(res, unquoteModField(fields[0]))
+		}
+	}
+	return res
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github/urie96/go-list-export/pkg/export"
+)
+
+// deprecatedSymbol is a deprecated symbol reported by the deprecations
+// command. Replacement is the symbol the notice points to instead, when
+// one could be made out.
+type deprecatedSymbol struct {
+	Key         string      `json:"key"`
+	Kind        export.Kind `json:"kind"`
+	Notice      string      `json:"notice"`
+	Replacement string      `json:"replacement,omitempty"`
+}
+
+// packageDeprecations groups the deprecated symbols of a package.
+type packageDeprecations struct {
+	Path       string             `json:"path"`
+	Deprecated []deprecatedSymbol `json:"deprecated"`
+}
+
+// replacementRe finds the symbol a deprecation notice recommends, in the
+// usual phrasings: "Use X instead", "replaced by [X]", "Prefer X.Y()",
+// "this function simply calls [X]".
+var replacementRe = regexp.MustCompile(`(?i)\b(?:use|prefer|replaced by|superseded by|in favou?r of|calls|simply)\s+(?:the\s+)?\[?(\*?[A-Za-z_][\w./]*(?:\(\))?)\]?`)
+
+// docLinkRe matches a doc link, as in "[os.ReadDir] is a better choice".
+var docLinkRe = regexp.MustCompile(`\[(\*?[A-Za-z_][\w./]*)\]`)
+
+// runDeprecations prints the deprecated symbols of the packages, grouped
+// by package, each with its deprecation notice and the replacement it
+// names. Packages without deprecated symbols are left out.
+func runDeprecations(cwd string, args []string) {
+	if len(args) == 0 {
+		usageError("deprecations: usage: deprecations packages...")
+	}
+	cfg := newConfig()
+	found := []packageDeprecations{}
+	for _, path := range expandArgs(args, cwd) {
+		pkg, err := loadPackage(cfg, path, cwd)
+		if pkg == nil {
+			report(err)
+			continue
+		}
+		filterPackage(pkg)
+		pkg.Sort(sortOrder)
+		pd := packageDeprecations{Path: path, Deprecated: []deprecatedSymbol{}}
+		for _, sym := range pkg.Symbols() {
+			if sym.Deprecated == "" {
+				continue
+			}
+			pd.Deprecated = append(pd.Deprecated, deprecatedSymbol{
+				Key:         sym.Key(),
+				Kind:        sym.Kind,
+				Notice:      sym.Deprecated,
+				Replacement: replacementHint(sym.Deprecated),
+			})
+		}
+		if len(pd.Deprecated) > 0 {
+			found = append(found, pd)
+		}
+	}
+
+	if *outputFormat == "json" {
+		encodeJSON(found)
+		return
+	}
+	for i, pd := range found {
+		if i > 0 {
+			fmt.Println()
+		}
+		fmt.Println(pd.Path)
+		for _, d := range pd.Deprecated {
+			if d.Replacement != "" {
+				fmt.Printf("\t%s (%s): use %s\n", d.Key, d.Kind, d.Replacement)
+			} else {
+				fmt.Printf("\t%s (%s)\n", d.Key, d.Kind)
+			}
+			fmt.Printf("\t\t%s\n", d.Notice)
+		}
+	}
+}
+
+// replacementHint returns the symbol a deprecation notice recommends
+// using instead, or failing a recognized phrasing the first symbol it
+// links to, or "" if it names none.
+func replacementHint(notice string) string {
+	for _, m := range replacementRe.FindAllStringSubmatch(notice, -1) {
+		hint := strings.TrimSuffix(strings.TrimRight(m[1], "."), "()")
+		if strings.ContainsAny(hint[:1], "ABCDEFGHIJKLMNOPQRSTUVWXYZ*") || strings.Contains(hint, ".") {
+			return hint
+		}
+		// an ordinary word, as in "use with care"
+	}
+	if m := docLinkRe.FindStringSubmatch(notice); m != nil {
+		return m[1]
+	}
+	return ""
+}
@@ -0,0 +1,232 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github/urie96/go-list-export/pkg/export"
+)
+
+// runDiff implements `go-list-export diff OLD NEW`, reporting the exported
+// symbols that were added, removed or changed between two packages,
+// typically two versions of one module (pkg@v1.2.0 pkg@v1.3.0). Two
+// "..." patterns, such as an upstream module and its fork, are compared
+// package by package; see runTreeDiff.
+func runDiff(cwd string, args []string) {
+	if len(args) == 2 && isPattern(args[0]) && isPattern(args[1]) {
+		runTreeDiff(cwd, args[0], args[1])
+		return
+	}
+	oldPkg, newPkg, changes := diffArgs("diff", cwd, args)
+	changes = filterChanges(changes)
+	if *outputFormat == "json" {
+		encodeJSON(changes)
+		return
+	}
+	fmt.Printf("// diff %s %s\n", pkgLabel(oldPkg), pkgLabel(newPkg))
+	printChanges(changes)
+}
+
+// runBump implements `go-list-export bump OLD NEW`, which classifies the
+// API changes between two versions and recommends the semver component to
+// increment for the release of NEW.
+func runBump(cwd string, args []string) {
+	oldPkg, _, changes := diffArgs("bump", cwd, args)
+	impact := export.ImpactOf(changes)
+	bump := impact.Bump()
+	next := nextVersion(oldPkg.Version, bump)
+
+	if *outputFormat == "json" {
+		encodeJSON(struct {
+			Impact  export.Impact    `json:"impact"`
+			Bump    string           `json:"bump"`
+			Next    string           `json:"next,omitempty"`
+			Changes []*export.Change `json:"changes"`
+		}{impact, bump, next, changes})
+		return
+	}
+	breaking, additive := []*export.Change{}, []*export.Change{}
+	for _, c := range changes {
+		if c.Breaking {
+			breaking = append(breaking, c)
+		} else {
+			additive = append(additive, c)
+		}
+	}
+	if len(breaking) > 0 {
+		fmt.Println("// breaking changes:")
+		printChanges(breaking)
+	}
+	if len(additive) > 0 {
+		fmt.Println("// additive changes:")
+		printChanges(additive)
+	}
+	if next != "" {
+		fmt.Printf("// impact: %s, recommended bump: %s (%s -> %s)\n", impact, bump, oldPkg.Version, next)
+	} else {
+		fmt.Printf("// impact: %s, recommended bump: %s\n", impact, bump)
+	}
+}
+
+// diffArgs loads the two packages named by args and diffs them.
+func diffArgs(cmd, cwd string, args []string) (oldPkg, newPkg *export.Package, changes []*export.Change) {
+	if len(args) != 2 {
+		usageError("usage: go-list-export %s OLD NEW", cmd)
+	}
+	cfg := newConfig()
+	cfg.OmitParamNames = true
+	var err error
+	if oldPkg, err = loadPackage(cfg, args[0], cwd); err != nil {
+		fatal(err)
+	}
+	if newPkg, err = loadPackage(cfg, args[1], cwd); err != nil {
+		fatal(err)
+	}
+	return oldPkg, newPkg, export.Diff(oldPkg, newPkg)
+}
+
+// filterChanges keeps the breaking changes with --breaking-only and the
+// added symbols with --additions-only.
+func filterChanges(changes []*export.Change) []*export.Change {
+	if !*breakingOnly && !*additionsOnly {
+		return changes
+	}
+	kept := []*export.Change{}
+	for _, c := range changes {
+		if *breakingOnly && c.Breaking || *additionsOnly && c.Kind == export.Added {
+			kept = append(kept, c)
+		}
+	}
+	return kept
+}
+
+// pkgLabel names a package as path@version, or just path when the version
+// is unknown.
+func pkgLabel(pkg *export.Package) string {
+	if pkg.Version == "" {
+		return pkg.Path
+	}
+	return pkg.Path + "@" + pkg.Version
+}
+
+// printChanges writes changes as text, one "+" line per added symbol, one
+// "-" line per removed symbol and a "-"/"+" pair per changed symbol.
+func printChanges(changes []*export.Change) {
+	for _, c := range changes {
+		switch c.Kind {
+		case export.Added:
+			fmt.Printf("+ %s\n", indentContinuation(c.New.Signature))
+		case export.Removed:
+			fmt.Printf("- %s\n", indentContinuation(c.Old.Signature))
+		case export.Changed:
+			fmt.Printf("- %s\n", indentContinuation(c.Old.Signature))
+			fmt.Printf("+ %s\n", indentContinuation(c.New.Signature))
+		}
+	}
+}
+
+// indentContinuation indents the continuation lines of a multi-line
+// signature so they line up after a two-character prefix.
+func indentContinuation(s string) string {
+	return strings.ReplaceAll(s, "\n", "\n  ")
+}
+
+// packageDiff is the comparison of a package present below the roots of
+// both patterns of a tree diff, or, with Old or New empty, a package found
+// below only one of them.
+type packageDiff struct {
+	Package string           `json:"package"`
+	Old     string           `json:"old,omitempty"`
+	New     string           `json:"new,omitempty"`
+	Changes []*export.Change `json:"changes,omitempty"`
+}
+
+// runTreeDiff compares the packages matched by two patterns, pairing them
+// by their directory below each pattern's root, so that a fork can be
+// compared with its upstream whatever their import paths. Packages below
+// only one root are reported as added or removed as a whole.
+func runTreeDiff(cwd, oldPattern, newPattern string) {
+	cfg := newConfig()
+	cfg.OmitParamNames = true
+	oldPkgs := treePackages(cfg, oldPattern, cwd)
+	newPkgs := treePackages(cfg, newPattern, cwd)
+
+	rels := []string{}
+	for rel := range oldPkgs {
+		rels = append(rels, rel)
+	}
+	for rel := range newPkgs {
+		if oldPkgs[rel] == nil {
+			rels = append(rels, rel)
+		}
+	}
+	sort.Strings(rels)
+
+	diffs := []packageDiff{}
+	for _, rel := range rels {
+		oldPkg, newPkg := oldPkgs[rel], newPkgs[rel]
+		d := packageDiff{Package: rel}
+		if oldPkg != nil {
+			d.Old = pkgLabel(oldPkg)
+		}
+		if newPkg != nil {
+			d.New = pkgLabel(newPkg)
+		}
+		switch {
+		case oldPkg != nil && newPkg != nil:
+			if d.Changes = filterChanges(export.Diff(oldPkg, newPkg)); len(d.Changes) == 0 {
+				continue
+			}
+		case oldPkg == nil && *breakingOnly, newPkg == nil && *additionsOnly:
+			continue
+		}
+		diffs = append(diffs, d)
+	}
+
+	if *outputFormat == "json" {
+		encodeJSON(diffs)
+		return
+	}
+	fmt.Printf("// diff %s %s\n", oldPattern, newPattern)
+	for _, d := range diffs {
+		switch {
+		case d.New == "":
+			fmt.Printf("\n// removed package %s\n", d.Old)
+		case d.Old == "":
+			fmt.Printf("\n// added package %s\n", d.New)
+		default:
+			fmt.Printf("\n// diff %s %s\n", d.Old, d.New)
+			printChanges(d.Changes)
+		}
+	}
+}
+
+// treePackages parses the packages matched by a "..." pattern, keyed by
+// their slash-separated directory below the pattern's root.
+func treePackages(cfg *export.Config, pattern, cwd string) map[string]*export.Package {
+	root := patternRoot(pattern)
+	rootDir, err := resolveOrDownloadDir(root, cwd)
+	if err != nil {
+		fatal(err)
+	}
+	rels, err := walkPackageDirs(rootDir, *maxDepth)
+	if err != nil {
+		report(err)
+	}
+	pkgs := map[string]*export.Package{}
+	for _, rel := range dropExcluded(root, dropInternal(rels)) {
+		pkg, err := parseDir(cfg, filepath.Join(rootDir, filepath.FromSlash(rel)))
+		if err != nil {
+			report(err)
+		}
+		if pkg == nil || pkg.Name == "" {
+			continue
+		}
+		pkg.Path = joinImportPath(root, rel)
+		pkg.Version = versionFromDir(pkg.Dir)
+		pkgs[rel] = pkg
+	}
+	return pkgs
+}
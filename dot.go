@@ -0,0 +1,103 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github/urie96/go-list-export/pkg/export"
+)
+
+// dotIdent matches the identifiers of a signature that may name a type of
+// the package: not preceded by a package qualifier.
+var dotIdent = regexp.MustCompile(`(^|[^.\w])([A-Z]\w*)`)
+
+// writeDot writes a Graphviz digraph of pkg whose nodes are its exported
+// types. An edge T -> U means U appears in the declaration of T (its
+// fields, methods or underlying type) or in a method of T. Functions that
+// return a type, the usual constructors, add an edge from the type to
+// each other type in their parameters.
+func writeDot(w io.Writer, pkg *export.Package, header bool) {
+	typeNames := map[string]bool{}
+	for _, sym := range pkg.Symbols() {
+		if sym.Kind == export.KindType {
+			typeNames[sym.Name] = true
+		}
+	}
+	edges := map[[2]string]bool{}
+	addEdges := func(from, text string) {
+		for _, m := range dotIdent.FindAllStringSubmatch(text, -1) {
+			if to := m[2]; typeNames[to] && to != from {
+				edges[[2]string{from, to}] = true
+			}
+		}
+	}
+	for _, sym := range pkg.Symbols() {
+		switch sym.Kind {
+		case export.KindType:
+			addEdges(sym.Name, strings.TrimPrefix(sym.Signature, "type "+sym.Name))
+		case export.KindMethod:
+			recv := receiverType(sym)
+			if typeNames[recv] {
+				_, rest, _ := strings.Cut(sym.Signature, ") ")
+				addEdges(recv, rest)
+			}
+		case export.KindFunc:
+			params, results := splitFuncSignature(sym.Signature)
+			for _, m := range dotIdent.FindAllStringSubmatch(results, -1) {
+				if typeNames[m[2]] {
+					addEdges(m[2], params)
+				}
+			}
+		}
+	}
+
+	name := pkg.Path
+	if name == "" {
+		name = pkg.Name
+	}
+	fmt.Fprintf(w, "digraph %q {\n", name)
+	fmt.Fprintln(w, "\tnode [shape=box];")
+	types := []string{}
+	for t := range typeNames {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	for _, t := range types {
+		fmt.Fprintf(w, "\t%q;\n", t)
+	}
+	list := [][2]string{}
+	for e := range edges {
+		list = append(list, e)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i][0] != list[j][0] {
+			return list[i][0] < list[j][0]
+		}
+		return list[i][1] < list[j][1]
+	})
+	for _, e := range list {
+		fmt.Fprintf(w, "\t%q -> %q;\n", e[0], e[1])
+	}
+	fmt.Fprintln(w, "}")
+}
+
+// splitFuncSignature splits "func F(params) results" after the parameter
+// list's closing parenthesis.
+func splitFuncSignature(sig string) (params, results string) {
+	depth := 0
+	for i, r := range sig {
+		switch r {
+		case '(', '[':
+			depth++
+		case ')', ']':
+			depth--
+			if depth == 0 && r == ')' {
+				return sig[:i+1], sig[i+1:]
+			}
+		}
+	}
+	return sig, ""
+}
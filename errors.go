@@ -0,0 +1,72 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github/urie96/go-list-export/pkg/export"
+)
+
+// Exit codes. Invalid flags also exit with exitUsage, via the flag package.
+const (
+	exitOK       = 0
+	exitError    = 1
+	exitUsage    = 2
+	exitNotFound = 3 // an argument could not be resolved to a package
+	exitParse    = 4 // a package has files that failed to parse
+	exitDrift    = 5 // check found declarations missing from or changed since the baseline
+)
+
+// exitCode is the status main exits with. It holds the code of the first
+// error reported.
+var exitCode = exitOK
+
+// notFoundError reports an argument that could not be resolved to a
+// package directory.
+type notFoundError struct {
+	arg    string
+	reason string
+}
+
+func (e *notFoundError) Error() string {
+	if e.reason != "" {
+		return fmt.Sprintf("%s: %s", e.arg, e.reason)
+	}
+	return fmt.Sprintf("module '%s' not found", e.arg)
+}
+
+// codeFor maps an error to the exit code that describes it.
+func codeFor(err error) int {
+	var nf *notFoundError
+	var pe *export.ParseError
+	switch {
+	case errors.As(err, &nf):
+		return exitNotFound
+	case errors.As(err, &pe):
+		return exitParse
+	default:
+		return exitError
+	}
+}
+
+// report prints err to stderr and records its exit code, letting the
+// caller carry on with the remaining arguments.
+func report(err error) {
+	fmt.Fprintf(os.Stderr, "go-list-export: %v\n", err)
+	if exitCode == exitOK {
+		exitCode = codeFor(err)
+	}
+}
+
+// fatal reports err and exits immediately.
+func fatal(err error) {
+	report(err)
+	os.Exit(exitCode)
+}
+
+// usageError prints a usage message and exits with exitUsage.
+func usageError(format string, args ...any) {
+	fmt.Fprintf(os.Stderr, "go-list-export: "+format+"\n", args...)
+	os.Exit(exitUsage)
+}
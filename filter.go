@@ -0,0 +1,164 @@
+package main
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"github/urie96/go-list-export/pkg/export"
+)
+
+// kindNames maps the values accepted by --only to symbol kinds.
+var kindNames = map[string]export.Kind{
+	"funcs":   export.KindFunc,
+	"methods": export.KindMethod,
+	"types":   export.KindType,
+	"vars":    export.KindVar,
+	"consts":  export.KindConst,
+}
+
+// The filters compiled from the flags by compileFilters; nil when the
+// corresponding flag is unset.
+var (
+	onlyKindSet    map[export.Kind]bool
+	matchRe        *regexp.Regexp
+	excludeMatchRe *regexp.Regexp
+	excludePkgRes  []*regexp.Regexp
+	stabilitySet   map[string]bool
+)
+
+// compileFilters validates and compiles the filter flags.
+func compileFilters() error {
+	var err error
+	if *onlyKinds != "" {
+		if onlyKindSet, err = parseKinds(*onlyKinds); err != nil {
+			return err
+		}
+	}
+	if *matchPattern != "" {
+		if matchRe, err = compileNamePattern("match", *matchPattern); err != nil {
+			return err
+		}
+	}
+	if *excludeMatchPattern != "" {
+		if excludeMatchRe, err = compileNamePattern("exclude-match", *excludeMatchPattern); err != nil {
+			return err
+		}
+	}
+	for _, pattern := range strings.Split(*excludeFiles, ",") {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("bad --exclude pattern '%s'", pattern)
+		}
+	}
+	if *excludePkgs != "" {
+		for _, pattern := range strings.Split(*excludePkgs, ",") {
+			excludePkgRes = append(excludePkgRes, packagePatternRe(pattern))
+		}
+	}
+	if *stability != "" {
+		stabilitySet = map[string]bool{}
+		for _, level := range strings.Split(*stability, ",") {
+			if level = strings.TrimSpace(level); level == "" {
+				return fmt.Errorf("empty level in --stability '%s'", *stability)
+			}
+			stabilitySet[strings.ToLower(level)] = true
+		}
+	}
+	return nil
+}
+
+// packagePatternRe compiles an import path pattern in which "..." matches
+// any string, as in the go command. A trailing "/..." also matches the
+// path before it.
+func packagePatternRe(pattern string) *regexp.Regexp {
+	re := regexp.QuoteMeta(pattern)
+	re = strings.ReplaceAll(re, `\.\.\.`, `.*`)
+	if strings.HasSuffix(re, `/.*`) {
+		re = strings.TrimSuffix(re, `/.*`) + `(/.*)?`
+	}
+	return regexp.MustCompile(`^` + re + `$`)
+}
+
+// parseKinds parses a comma-separated --only value.
+func parseKinds(s string) (map[export.Kind]bool, error) {
+	kinds := map[export.Kind]bool{}
+	for _, name := range strings.Split(s, ",") {
+		kind, ok := kindNames[strings.TrimSpace(name)]
+		if !ok {
+			return nil, fmt.Errorf("unknown kind '%s' in --only, want funcs, methods, types, vars or consts", name)
+		}
+		kinds[kind] = true
+	}
+	return kinds, nil
+}
+
+// compileNamePattern compiles a --match or --exclude-match value. The
+// pattern must match a whole name.
+func compileNamePattern(flagName, pattern string) (*regexp.Regexp, error) {
+	re, err := regexp.Compile("^(?:" + pattern + ")$")
+	if err != nil {
+		return nil, fmt.Errorf("invalid --%s pattern: %v", flagName, err)
+	}
+	return re, nil
+}
+
+// matchName reports whether re matches the name of sym or, for methods,
+// its receiver-qualified key such as "Client.Do".
+func matchName(re *regexp.Regexp, sym *export.Symbol) bool {
+	return re.MatchString(sym.Name) || (sym.Kind == export.KindMethod && re.MatchString(sym.Key()))
+}
+
+// filterPackage drops the symbols excluded by the filter flags.
+func filterPackage(pkg *export.Package) {
+	if onlyKindSet != nil {
+		pkg.Filter(func(sym *export.Symbol) bool {
+			return onlyKindSet[sym.Kind]
+		})
+	}
+	if matchRe != nil {
+		pkg.Filter(func(sym *export.Symbol) bool {
+			return matchName(matchRe, sym)
+		})
+	}
+	if excludeMatchRe != nil {
+		pkg.Filter(func(sym *export.Symbol) bool {
+			return !matchName(excludeMatchRe, sym)
+		})
+	}
+	if *excludeDeprecated {
+		deprecatedTypes := map[string]bool{}
+		for _, sym := range pkg.Symbols() {
+			if sym.Kind == export.KindType && sym.Deprecated != "" {
+				deprecatedTypes[sym.Name] = true
+			}
+		}
+		pkg.Filter(func(sym *export.Symbol) bool {
+			return sym.Deprecated == "" && !(sym.Kind == export.KindMethod && deprecatedTypes[receiverType(sym)])
+		})
+	}
+	if stabilitySet != nil {
+		typeLevels := map[string]string{}
+		for _, sym := range pkg.Symbols() {
+			if sym.Kind == export.KindType {
+				typeLevels[sym.Name] = sym.Stability
+			}
+		}
+		pkg.Filter(func(sym *export.Symbol) bool {
+			level := sym.Stability
+			if level == "" && sym.Kind == export.KindMethod {
+				level = typeLevels[receiverType(sym)]
+			}
+			if level == "" {
+				level = "unmarked"
+			}
+			return stabilitySet[level]
+		})
+	}
+}
+
+// receiverType returns the base type name of a method's receiver.
+func receiverType(sym *export.Symbol) string {
+	typ, _, _ := strings.Cut(sym.Key(), ".")
+	return typ
+}
@@ -0,0 +1,259 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github/urie96/go-list-export/pkg/export"
+)
+
+// formatter writes one package to w. header asks the format to announce
+// the package before its files; formats that always do so ignore it.
+type formatter func(w io.Writer, pkg *export.Package, header bool)
+
+// formatters maps the names accepted by --format to their implementations.
+var formatters = map[string]formatter{
+	"text":      writeText,
+	"json":      writeJSON,
+	"markdown":  writeMarkdown,
+	"yaml":      writeYAML,
+	"ctags":     writeCtags,
+	"etags":     writeEtags,
+	"lsp":       writeLSP,
+	"apitxt":    writeAPITxt,
+	"dot":       writeDot,
+	"template":  writeTemplate,
+	"html":      writeHTML,
+	"tree":      writeTree,
+	"protobuf":  writeProtobuf,
+	"textproto": writeTextproto,
+	"sexp":      writeSexp,
+	"org":       writeOrg,
+	"asciidoc":  writeAsciiDoc,
+	"rst":       writeRST,
+	"godoc":     writeGoDoc,
+}
+
+func writeText(w io.Writer, pkg *export.Package, header bool) {
+	if header || *showHeader {
+		writeTextHeader(w, pkg)
+	}
+	writeTextBody(w, pkg)
+}
+
+// writeTextHeader writes the comment lines that announce pkg in the text
+// format.
+func writeTextHeader(w io.Writer, pkg *export.Package) {
+	fmt.Fprintf(w, "// package %s\n", pkg.Path)
+	if pkg.Module != "" {
+		fmt.Fprintf(w, "// module %s\n", strings.TrimSpace(pkg.Module+" "+pkg.Version))
+	}
+	if pkg.Dir != "" {
+		fmt.Fprintf(w, "// dir %s\n", pkg.Dir)
+	}
+	fmt.Fprintln(w)
+}
+
+// writeTextBody writes the package doc, files and examples of pkg in the
+// text format.
+func writeTextBody(w io.Writer, pkg *export.Package) {
+	if pkg.Doc != "" {
+		writeDoc(w, "", pkg.Doc)
+		fmt.Fprintln(w, "")
+	}
+	for _, f := range pkg.Files {
+		if f.Name != "" { // not combined
+			fmt.Fprintf(w, "// %s%s:\n", f.Name, fileNotes(f))
+		}
+		for i, sym := range f.Symbols {
+			if sym.Enum == "" {
+				writeDoc(w, "", sym.Doc)
+				fmt.Fprintln(w, sym.Signature+annotation(sym))
+				continue
+			}
+			// Enum constants are printed as a const block.
+			if i == 0 || f.Symbols[i-1].Enum != sym.Enum {
+				fmt.Fprintln(w, "const (")
+			}
+			writeDoc(w, "\t", sym.Doc)
+			fmt.Fprintln(w, "\t"+strings.TrimPrefix(sym.Signature, "const ")+annotation(sym))
+			if i == len(f.Symbols)-1 || f.Symbols[i+1].Enum != sym.Enum {
+				fmt.Fprintln(w, ")")
+			}
+		}
+		fmt.Fprintln(w, "")
+	}
+	if len(pkg.Examples) > 0 {
+		fmt.Fprintln(w, "// examples:")
+		for _, ex := range pkg.Examples {
+			fmt.Fprintf(w, "func %s() // %s\n", ex.Name, exampleTarget(ex))
+		}
+		fmt.Fprintln(w, "")
+	}
+	if len(pkg.Generate) > 0 {
+		fmt.Fprintln(w, "// go:generate directives:")
+		for _, d := range pkg.Generate {
+			fmt.Fprintf(w, "//go:generate %s // %s:%d\n", d.Command, d.File, d.Line)
+		}
+		fmt.Fprintln(w, "")
+	}
+}
+
+// fileNotes returns the notes of a file heading: " (package name)" for
+// the files of another package than the one listed, as external test
+// files are, " (generated)" for generated files, both as
+// " (package name, generated)", or "".
+func fileNotes(f *export.File) string {
+	var notes []string
+	if f.Package != "" {
+		notes = append(notes, "package "+f.Package)
+	}
+	if f.Generated {
+		notes = append(notes, "generated")
+	}
+	if len(notes) == 0 {
+		return ""
+	}
+	return " (" + strings.Join(notes, ", ") + ")"
+}
+
+// exampleTarget describes what an example demonstrates.
+func exampleTarget(ex *export.Example) string {
+	if ex.Symbol == "" {
+		return "package"
+	}
+	return ex.Symbol
+}
+
+// annotation returns a trailing comment with facts about sym that its
+// signature does not show, such as the platforms it is limited to.
+func annotation(sym *export.Symbol) string {
+	notes := []string{}
+	if sym.Position != "" {
+		notes = append(notes, sym.Position)
+	}
+	if sym.URL != "" {
+		notes = append(notes, sym.URL)
+	}
+	if sym.Promoted != "" {
+		notes = append(notes, "promoted from "+sym.Promoted)
+	}
+	switch sym.NoBody {
+	case "assembly":
+		notes = append(notes, "implemented in assembly")
+	case "external":
+		notes = append(notes, "implemented externally")
+	}
+	if sym.Internal {
+		notes = append(notes, "internal")
+	}
+	if sym.Cgo {
+		notes = append(notes, "cgo")
+	}
+	if sym.Deprecated != "" {
+		notes = append(notes, "Deprecated")
+	}
+	if sym.Stability != "" {
+		notes = append(notes, sym.Stability)
+	}
+	if len(sym.Platforms) > 0 {
+		notes = append(notes, strings.Join(sym.Platforms, ", ")+" only")
+	}
+	if len(notes) == 0 {
+		return ""
+	}
+	return " // " + strings.Join(notes, "; ")
+}
+
+// writeDoc writes a doc comment as "//" comment lines, each starting with
+// indent.
+func writeDoc(w io.Writer, indent, doc string) {
+	if doc == "" {
+		return
+	}
+	for _, line := range strings.Split(doc, "\n") {
+		if line == "" {
+			fmt.Fprintln(w, indent+"//")
+		} else {
+			fmt.Fprintln(w, indent+"// "+line)
+		}
+	}
+}
+
+func writeJSON(w io.Writer, pkg *export.Package, header bool) {
+	writeJSONValue(w, struct {
+		SchemaVersion string `json:"schema_version"`
+		*export.Package
+	}{export.SchemaVersion, pkg})
+}
+
+// writeJSONValue writes v to w as indented JSON.
+func writeJSONValue(w io.Writer, v any) {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		fatal(err)
+	}
+}
+
+// encodeJSON writes v to stdout as indented JSON.
+func encodeJSON(v any) {
+	writeJSONValue(os.Stdout, v)
+}
+
+// writeMarkdown writes a heading per package and per file. Signatures go
+// into fenced code blocks and doc comments become prose between them;
+// consecutive undocumented symbols share one code block.
+func writeMarkdown(w io.Writer, pkg *export.Package, header bool) {
+	fmt.Fprintf(w, "# package `%s`", pkg.Path)
+	if pkg.Version != "" {
+		fmt.Fprintf(w, " (%s)", pkg.Version)
+	}
+	fmt.Fprint(w, "\n\n")
+	if pkg.Module != "" {
+		fmt.Fprintf(w, "Module `%s`, read from `%s`.\n\n", pkg.Module, pkg.Dir)
+	}
+	if pkg.Doc != "" {
+		fmt.Fprintf(w, "%s\n\n", pkg.Doc)
+	}
+	for _, f := range pkg.Files {
+		if f.Name != "" { // not combined
+			fmt.Fprintf(w, "## %s%s\n\n", f.Name, fileNotes(f))
+		}
+		inBlock := false
+		for _, sym := range f.Symbols {
+			if sym.Doc != "" {
+				if inBlock {
+					fmt.Fprint(w, "```\n\n")
+					inBlock = false
+				}
+				fmt.Fprintf(w, "%s\n\n", sym.Doc)
+			}
+			if !inBlock {
+				fmt.Fprintln(w, "```go")
+				inBlock = true
+			}
+			fmt.Fprintln(w, sym.Signature+annotation(sym))
+		}
+		if inBlock {
+			fmt.Fprint(w, "```\n\n")
+		}
+	}
+	if len(pkg.Examples) > 0 {
+		fmt.Fprint(w, "## Examples\n\n")
+		for _, ex := range pkg.Examples {
+			fmt.Fprintf(w, "- `%s` (%s)\n", ex.Name, exampleTarget(ex))
+		}
+		fmt.Fprintln(w, "")
+	}
+	if len(pkg.Generate) > 0 {
+		fmt.Fprint(w, "## go:generate\n\n")
+		for _, d := range pkg.Generate {
+			fmt.Fprintf(w, "- `%s` (%s:%d)\n", d.Command, d.File, d.Line)
+		}
+		fmt.Fprintln(w, "")
+	}
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github/urie96/go-list-export/pkg/export"
+)
+
+// runFuzzySearch prints the --limit best matches of a fuzzy query among
+// the exported symbols of the standard library and of the preferred
+// cached version of every module, or with --index among those of the
+// index database. The query's letters
+// must occur in order, ignoring case, in "pkg.Name" or "pkg.Type.Method",
+// where pkg is the last element of the import path, so "jsonmarsh" finds
+// json.Marshal. See fuzzyScore for the ranking.
+func runFuzzySearch(args []string) {
+	query := strings.Join(args, "")
+	hits := []searchHit{}
+	if *useIndex {
+		found, err := queryIndex(fmt.Sprintf("package || '.' || key LIKE %s ESCAPE '\\'", sqlQuote(likeSubsequence(query))))
+		if err != nil {
+			fatal(err)
+		}
+		for _, hit := range found {
+			if hit.Score = hitScore(query, hit); hit.Score > 0 {
+				hits = append(hits, hit)
+			}
+		}
+	} else {
+		cfg := newConfig()
+		pkgs := stdPackages()
+		for _, p := range cachedPackages(cachedModules(false)) {
+			pkgs = append(pkgs, searchTarget{filepath.Join(p.Module.Dir, filepath.FromSlash(p.Rel)), p.importPath(), p.Module.Version})
+		}
+		results := make([][]searchHit, len(pkgs))
+		orderedParallel(len(pkgs), func(i int) {
+			results[i] = fuzzySearchPackage(cfg, pkgs[i], query)
+		}, func(i int) {
+			hits = append(hits, results[i]...)
+			results[i] = nil
+		})
+	}
+	sort.SliceStable(hits, func(i, j int) bool { return hits[i].Score > hits[j].Score })
+	if *searchLimit > 0 && len(hits) > *searchLimit {
+		hits = hits[:*searchLimit]
+	}
+	if *outputFormat == "json" {
+		encodeJSON(hits)
+		return
+	}
+	for _, hit := range hits {
+		printHit(nil, hit)
+	}
+}
+
+// searchTarget is a package directory searched by runFuzzySearch.
+type searchTarget struct {
+	dir, path, version string
+}
+
+// stdPackages returns the packages of the standard library, leaving out
+// commands and internal packages.
+func stdPackages() []searchTarget {
+	if goRoot() == "" {
+		return nil
+	}
+	src := filepath.Join(goRoot(), "src")
+	rels, _ := walkPackageDirs(src, -1)
+	pkgs := []searchTarget{}
+	for _, rel := range rels {
+		if rel == "." || rel == "cmd" || strings.HasPrefix(rel, "cmd/") || isInternalPath(rel) {
+			continue
+		}
+		pkgs = append(pkgs, searchTarget{filepath.Join(src, filepath.FromSlash(rel)), rel, goRootVersion()})
+	}
+	return pkgs
+}
+
+// fuzzySearchPackage returns the symbols of p matching query.
+func fuzzySearchPackage(cfg *export.Config, p searchTarget, query string) []searchHit {
+	pkg, _ := parseDir(cfg, p.dir)
+	if pkg == nil || pkg.Name == "" {
+		return nil
+	}
+	filterPackage(pkg)
+	hits := []searchHit{}
+	for _, f := range pkg.Files {
+		for _, sym := range f.Symbols {
+			hit := searchHit{Path: p.path, Version: p.version, File: f.Name, Symbol: sym}
+			if hit.Score = hitScore(query, hit); hit.Score > 0 {
+				hits = append(hits, hit)
+			}
+		}
+	}
+	return hits
+}
+
+// likeSubsequence returns the SQL LIKE pattern matching the strings that
+// contain the letters of query in order.
+func likeSubsequence(query string) string {
+	var b strings.Builder
+	b.WriteByte('%')
+	for _, r := range query {
+		if r == '%' || r == '_' || r == '\\' {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+		b.WriteByte('%')
+	}
+	return b.String()
+}
+
+// hitScore ranks a search hit for query: the quality of the match against
+// "pkg.Key" from fuzzyScore, plus a little for the kinds of symbols most
+// often looked for and minus a little for deprecated ones. It is zero if
+// the query does not match.
+func hitScore(query string, hit searchHit) int {
+	score := fuzzyScore(query, path.Base(hit.Path)+"."+hit.Symbol.Key())
+	if score == 0 {
+		return 0
+	}
+	switch hit.Symbol.Kind {
+	case export.KindFunc, export.KindType:
+		score += 3
+	case export.KindMethod:
+		score++
+	}
+	if hit.Symbol.Deprecated != "" {
+		score -= 5
+	}
+	if score < 1 {
+		score = 1
+	}
+	return score
+}
+
+// fuzzyScore scores how well query matches target as a subsequence,
+// ignoring case, or returns 0 if it does not. Every matched letter counts,
+// more so at the start of a word (after a dot, slash or underscore, or an
+// upper case letter after a lower case one) and right after the previous
+// match; letters skipped between matches and the length of target count
+// against it. A query equal to the name after the last dot scores best.
+func fuzzyScore(query, target string) int {
+	q := []rune(strings.ToLower(query))
+	t := []rune(target)
+	if len(q) == 0 || len(q) > len(t) {
+		return 0
+	}
+	const none = -1 << 30
+	// best[i][j] is the best score matching q[:i+1] with q[i] at t[j].
+	best := make([][]int, len(q))
+	for i := range best {
+		best[i] = make([]int, len(t))
+		for j := range t {
+			best[i][j] = none
+			if unicode.ToLower(t[j]) != q[i] {
+				continue
+			}
+			bonus := 1
+			if j == 0 || strings.ContainsRune("./_", t[j-1]) || unicode.IsUpper(t[j]) && unicode.IsLower(t[j-1]) {
+				bonus += 8
+			}
+			if i == 0 {
+				best[i][j] = bonus
+				continue
+			}
+			for k := 0; k < j; k++ {
+				if best[i-1][k] == none {
+					continue
+				}
+				s := best[i-1][k] + bonus
+				if k == j-1 {
+					s += 5
+				} else {
+					s -= j - k - 1
+				}
+				if s > best[i][j] {
+					best[i][j] = s
+				}
+			}
+		}
+	}
+	score := none
+	for j, s := range best[len(q)-1] {
+		if s != none && s-(len(t)-1-j) > score {
+			score = s - (len(t) - 1 - j) // letters left after the last match
+		}
+	}
+	if score == none {
+		return 0
+	}
+	score += 20 - len(t)/4
+	name := strings.ToLower(target[strings.LastIndex(target, ".")+1:])
+	if strings.HasSuffix(string(q), name) {
+		score += 20
+	}
+	if score < 1 {
+		score = 1
+	}
+	return score
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"os"
+	"sort"
+	"strings"
+
+	"github/urie96/go-list-export/pkg/export"
+)
+
+// splitTypeArg splits a "pkg.Type" argument, such as "net/http.Client" or
+// "github.com/google/uuid.UUID", at the last dot after the last slash.
+func splitTypeArg(arg string) (pkgArg, typeName string, ok bool) {
+	slash := strings.LastIndex(arg, "/")
+	dot := strings.LastIndex(arg[slash+1:], ".")
+	if dot < 0 {
+		return "", "", false
+	}
+	dot += slash + 1
+	if dot == 0 || dot == len(arg)-1 {
+		return "", "", false
+	}
+	return arg[:dot], arg[dot+1:], true
+}
+
+// typeSource is the method set of a type named by a "pkg.Type" argument.
+type typeSource struct {
+	pkg      *export.Package
+	typeName string
+	methods  []*export.Method
+	imports  map[string]string
+}
+
+// loadTypeArgs type-checks the package of every "pkg.Type" argument and
+// returns the method sets of the types, for the code generating commands.
+func loadTypeArgs(cmd, cwd string, args []string) []*typeSource {
+	if len(args) == 0 {
+		usageError("%s: no types given", cmd)
+	}
+	cfg := newConfig()
+	cfg.Types = true
+	res := []*typeSource{}
+	for _, arg := range args {
+		pkgArg, typeName, ok := splitTypeArg(arg)
+		if !ok {
+			usageError("%s: argument %s is not of the form pkg.Type", cmd, arg)
+		}
+		pkg, err := loadPackage(cfg, pkgArg, cwd)
+		if pkg == nil {
+			report(err)
+			continue
+		}
+		methods, imports, err := pkg.MethodSet(typeName)
+		if err != nil {
+			report(err)
+			continue
+		}
+		res = append(res, &typeSource{pkg, typeName, methods, imports})
+	}
+	if exitCode != exitOK {
+		os.Exit(exitCode)
+	}
+	return res
+}
+
+// methodParams formats the parameters of m. Unnamed parameters are left
+// unnamed unless withNames is set, in which case they are named p0, p1 and
+// so on, as method bodies need them to be.
+func methodParams(m *export.Method, withNames bool) string {
+	list := []string{}
+	for i, p := range m.Params {
+		typ := p.Type
+		if m.Variadic && i == len(m.Params)-1 {
+			typ = "..." + typ
+		}
+		name := p.Name
+		if withNames && (name == "" || name == "_") {
+			name = fmt.Sprintf("p%d", i)
+		}
+		if name != "" {
+			typ = name + " " + typ
+		}
+		list = append(list, typ)
+	}
+	return strings.Join(list, ", ")
+}
+
+// methodResults formats the results of m, including the parentheses
+// needed around several or named results.
+func methodResults(m *export.Method) string {
+	if len(m.Results) == 0 {
+		return ""
+	}
+	list := []string{}
+	for _, r := range m.Results {
+		if r.Name != "" {
+			list = append(list, r.Name+" "+r.Type)
+		} else {
+			list = append(list, r.Type)
+		}
+	}
+	if len(list) == 1 && m.Results[0].Name == "" {
+		return " " + list[0]
+	}
+	return " (" + strings.Join(list, ", ") + ")"
+}
+
+// printGoSource prints a gofmt-formatted Go file of package pkgName that
+// imports the given packages (import path to name) and declares body.
+func printGoSource(pkgName string, imports map[string]string, body string) {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "// Code generated by go-list-export %s; DO NOT EDIT.\n\n", strings.Join(os.Args[1:], " "))
+	fmt.Fprintf(&b, "package %s\n\n", pkgName)
+	paths := []string{}
+	for path := range imports {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	if len(paths) > 0 {
+		b.WriteString("import (\n")
+		for _, path := range paths {
+			name := imports[path]
+			if name == lastElem(path) {
+				fmt.Fprintf(&b, "\t%q\n", path)
+			} else {
+				fmt.Fprintf(&b, "\t%s %q\n", name, path)
+			}
+		}
+		b.WriteString(")\n\n")
+	}
+	b.WriteString(body)
+	src, err := format.Source(b.Bytes())
+	if err != nil {
+		// Print what was generated anyway, so the problem can be seen.
+		os.Stdout.Write(b.Bytes())
+		fatal(fmt.Errorf("formatting generated code: %w", err))
+	}
+	os.Stdout.Write(src)
+}
+
+// lastElem returns the last element of a slash-separated import path.
+func lastElem(path string) string {
+	return path[strings.LastIndex(path, "/")+1:]
+}
+
+// mergeImports adds the imports of src to imports, which maps import
+// paths to names, failing if two different packages would need the same
+// name.
+func mergeImports(imports map[string]string, src *typeSource) error {
+	for path, name := range src.imports {
+		for otherPath, otherName := range imports {
+			if name == otherName && path != otherPath {
+				return fmt.Errorf("packages %s and %s are both named %s; generate their code separately", path, otherPath, name)
+			}
+		}
+		imports[path] = name
+	}
+	return nil
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io"
+	"sort"
+	"strings"
+
+	"github/urie96/go-list-export/pkg/export"
+)
+
+// writeGoDoc writes pkg the way `go doc -all` presents a package: the
+// package clause and comment, then sections of constants, variables,
+// functions and types, each declaration followed by its doc comment
+// indented by four spaces. As in go doc, the constants and variables of a
+// type, the functions constructing it and its methods are listed after
+// the type; functions, types and methods are sorted by name.
+func writeGoDoc(w io.Writer, pkg *export.Package, header bool) {
+	fmt.Fprintf(w, "package %s // import %q\n\n", pkg.Name, pkg.Path)
+	if pkg.Doc != "" {
+		fmt.Fprintf(w, "%s\n\n", pkg.Doc)
+	}
+
+	typeNames := map[string]bool{}
+	for _, sym := range pkg.Symbols() {
+		if sym.Kind == export.KindType {
+			typeNames[sym.Name] = true
+		}
+	}
+	var consts, vars, funcs, types []*export.Symbol
+	byType := map[string][]*export.Symbol{}
+	for _, sym := range pkg.Symbols() {
+		if t := goDocType(sym); t != "" && typeNames[t] {
+			byType[t] = append(byType[t], sym)
+			continue
+		}
+		switch sym.Kind {
+		case export.KindConst:
+			consts = append(consts, sym)
+		case export.KindVar:
+			vars = append(vars, sym)
+		case export.KindFunc:
+			funcs = append(funcs, sym)
+		case export.KindType:
+			types = append(types, sym)
+		}
+	}
+	byName := func(syms []*export.Symbol) {
+		sort.SliceStable(syms, func(i, j int) bool { return syms[i].Name < syms[j].Name })
+	}
+	byName(funcs)
+	byName(types)
+
+	writeGoDocSection(w, "CONSTANTS", consts)
+	writeGoDocSection(w, "VARIABLES", vars)
+	writeGoDocSection(w, "FUNCTIONS", funcs)
+	if len(types) > 0 {
+		fmt.Fprint(w, "TYPES\n\n")
+	}
+	for _, typ := range types {
+		writeGoDocSymbol(w, typ)
+		var values, ctors, methods []*export.Symbol
+		for _, sym := range byType[typ.Name] {
+			switch sym.Kind {
+			case export.KindConst, export.KindVar:
+				values = append(values, sym)
+			case export.KindFunc:
+				ctors = append(ctors, sym)
+			default:
+				methods = append(methods, sym)
+			}
+		}
+		byName(ctors)
+		byName(methods)
+		for _, group := range [][]*export.Symbol{values, ctors, methods} {
+			for _, sym := range group {
+				writeGoDocSymbol(w, sym)
+			}
+		}
+	}
+}
+
+func writeGoDocSection(w io.Writer, title string, syms []*export.Symbol) {
+	if len(syms) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "%s\n\n", title)
+	for _, sym := range syms {
+		writeGoDocSymbol(w, sym)
+	}
+}
+
+// writeGoDocSymbol writes a declaration and its doc comment, indented.
+func writeGoDocSymbol(w io.Writer, sym *export.Symbol) {
+	fmt.Fprintln(w, sym.Signature+annotation(sym))
+	if sym.Doc != "" {
+		lines := strings.Split(sym.Doc, "\n")
+		for i, line := range lines {
+			if line != "" {
+				lines[i] = "    " + line
+			}
+		}
+		fmt.Fprintln(w, strings.Join(lines, "\n"))
+	}
+	fmt.Fprintln(w)
+}
+
+// goDocType returns the type a symbol is listed under by go doc: the
+// receiver of a method, the declared type of a constant or variable and
+// the first result of a function, without pointers. It returns "" for
+// types and for symbols that have no such named type.
+func goDocType(sym *export.Symbol) string {
+	if sym.Kind == export.KindType {
+		return ""
+	}
+	if sym.Kind == export.KindMethod {
+		return receiverType(sym)
+	}
+	if sym.Enum != "" {
+		return sym.Enum
+	}
+	f, err := parser.ParseFile(token.NewFileSet(), "", "package p\n"+sym.Signature, 0)
+	if err != nil || len(f.Decls) == 0 {
+		return ""
+	}
+	var typ ast.Expr
+	switch decl := f.Decls[0].(type) {
+	case *ast.FuncDecl:
+		if res := decl.Type.Results; res != nil && len(res.List) > 0 {
+			typ = res.List[0].Type
+		}
+	case *ast.GenDecl:
+		if spec, ok := decl.Specs[0].(*ast.ValueSpec); ok {
+			typ = spec.Type
+		}
+	}
+	if star, ok := typ.(*ast.StarExpr); ok {
+		typ = star.X
+	}
+	switch t := typ.(type) {
+	case *ast.Ident:
+		return t.Name
+	case *ast.IndexExpr: // an instantiated generic type
+		if id, ok := t.X.(*ast.Ident); ok {
+			return id.Name
+		}
+	case *ast.IndexListExpr:
+		if id, ok := t.X.(*ast.Ident); ok {
+			return id.Name
+		}
+	}
+	return ""
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github/urie96/go-list-export/pkg/export"
+)
+
+// packageHash is a line of the --hash report.
+type packageHash struct {
+	Path string `json:"path"`
+	Hash string `json:"hash"`
+}
+
+// apiHashes collects the --hash report as packages are listed.
+var apiHashes []packageHash
+
+// addAPIHash records the digest of the API of pkg. The API is normalized
+// to its apitxt lines, so the digest ignores doc comments, parameter
+// names, declaration order and the module version, and interface{} is
+// written as any. newConfig type-checks the packages for --hash, so that
+// the lines hold the types of vars inferred from their initializers.
+func addAPIHash(pkg *export.Package, header bool) {
+	var buf bytes.Buffer
+	writeAPITxt(&buf, pkg, header)
+	h := sha256.Sum256([]byte(strings.ReplaceAll(buf.String(), "interface{}", "any")))
+	apiHashes = append(apiHashes, packageHash{Path: pkg.Path, Hash: hex.EncodeToString(h[:])})
+}
+
+// printAPIHashes prints the --hash report: a SHA-256 digest per package in
+// the format of sha256sum, then one over all of them, which is
+// independent of the order the packages were listed in.
+func printAPIHashes() {
+	sorted := append([]packageHash(nil), apiHashes...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Path < sorted[j].Path })
+	total := sha256.New()
+	for _, ph := range sorted {
+		fmt.Fprintf(total, "%s  %s\n", ph.Hash, ph.Path)
+	}
+	sum := hex.EncodeToString(total.Sum(nil))
+
+	if *outputFormat == "json" {
+		encodeJSON(struct {
+			Packages []packageHash `json:"packages"`
+			Hash     string        `json:"hash"`
+		}{apiHashes, sum})
+		return
+	}
+	for _, ph := range apiHashes {
+		fmt.Printf("%s  %s\n", ph.Hash, ph.Path)
+	}
+	fmt.Printf("%s  total\n", sum)
+}
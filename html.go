@@ -0,0 +1,142 @@
+package main
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github/urie96/go-list-export/pkg/export"
+)
+
+// htmlPage is a page written into --html-dir, listed by its index.html.
+type htmlPage struct {
+	Path, File string
+}
+
+var htmlPages []htmlPage
+
+// writeHTML writes pkg as a standalone HTML page, with an anchor per symbol
+// named by its key and a sidebar indexing them. With --html-dir the page
+// links back to the index.
+func writeHTML(w io.Writer, pkg *export.Package, header bool) {
+	data := map[string]any{"Pkg": pkg, "Links": typeLinker(pkg), "Index": *htmlDir != ""}
+	if err := staticPages.ExecuteTemplate(w, "package", data); err != nil {
+		fatal(err)
+	}
+}
+
+// writeHTMLPage writes the page of pkg into --html-dir, for
+// writeHTMLIndex to list.
+func writeHTMLPage(pkg *export.Package, header bool) {
+	page := htmlPage{Path: pkg.Path, File: packageFileName(pkg.Path) + ".html"}
+	data := map[string]any{"Pkg": pkg, "Links": typeLinker(pkg), "Index": true}
+	if err := writeHTMLFile(page.File, "package", data); err != nil {
+		report(err)
+		return
+	}
+	htmlPages = append(htmlPages, page)
+}
+
+// writeHTMLIndex writes the index.html of --html-dir, linking the pages
+// written by writeHTML.
+func writeHTMLIndex() {
+	if err := writeHTMLFile("index.html", "index", map[string]any{"Pages": htmlPages}); err != nil {
+		report(err)
+	}
+}
+
+func writeHTMLFile(name, tmpl string, data map[string]any) error {
+	if err := os.MkdirAll(*htmlDir, 0o755); err != nil {
+		return err
+	}
+	f, err := os.Create(filepath.Join(*htmlDir, name))
+	if err != nil {
+		return err
+	}
+	if err := staticPages.ExecuteTemplate(f, tmpl, data); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// packageFileName returns the base name of the files written for the
+// package with the given path: the path with its slashes replaced by
+// underscores and every other character than ASCII letters, digits, dots
+// and dashes, underscores included, escaped as ~ and its hex code, as is
+// a leading dot so that local paths such as ./foo do not name hidden
+// files. Distinct paths thus get distinct names: a/b_c becomes a_b~5fc,
+// a/b/c becomes a_b_c, ./foo becomes ~2e_foo and /foo becomes _foo.
+func packageFileName(path string) string {
+	var b strings.Builder
+	for i, c := range []byte(path) {
+		switch {
+		case c == '/':
+			b.WriteByte('_')
+		case c == '.' && i == 0:
+			b.WriteString("~2e")
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9', c == '.', c == '-':
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "~%02x", c)
+		}
+	}
+	return b.String()
+}
+
+var staticPages = template.Must(template.New("").Funcs(template.FuncMap{
+	"annotation": annotation,
+}).Parse(`
+{{define "head"}}<!DOCTYPE html>
+<html><head><meta charset="utf-8"><title>{{.}}</title>
+<style>
+body { font-family: sans-serif; margin: 0; display: flex; }
+nav { position: sticky; top: 0; height: 100vh; overflow-y: auto; width: 18em; flex: none; padding: 1em; background: #f6f8fa; border-right: 1px solid #ddd; box-sizing: border-box; font-size: 90%; }
+nav ul { list-style: none; padding: 0; margin: 0; }
+nav li { margin: .15em 0; }
+nav .kind { color: #888; font-size: 80%; }
+main { max-width: 60em; padding: 1em 2em; min-width: 0; }
+a { color: #0366d6; text-decoration: none; }
+a:hover { text-decoration: underline; }
+pre { background: #f6f8fa; padding: .5em; margin: .3em 0 1em; white-space: pre-wrap; }
+.doc { white-space: pre-wrap; margin: 0; }
+.deprecated { color: #888; }
+</style></head><body>
+{{end}}
+
+{{define "package"}}{{template "head" .Pkg.Path}}
+<nav>
+{{if .Index}}<p><a href="index.html">index</a></p>{{end}}
+<p><b>package {{.Pkg.Name}}</b></p>
+<ul>{{range .Pkg.Symbols}}<li><a href="#{{.Key}}"{{if .Deprecated}} class="deprecated"{{end}}>{{.Key}}</a> <span class="kind">{{.Kind}}</span></li>{{end}}</ul>
+{{if .Pkg.Examples}}<p><a href="#examples">Examples</a></p>{{end}}
+{{if .Pkg.Generate}}<p><a href="#generate">go:generate</a></p>{{end}}
+</nav>
+<main>
+<h1>package {{.Pkg.Name}}</h1>
+<p><code>{{.Pkg.Path}}</code>{{with .Pkg.Version}} {{.}}{{end}}</p>
+{{with .Pkg.Module}}<p>Module <code>{{.}}</code>, read from <code>{{$.Pkg.Dir}}</code>.</p>{{end}}
+{{with .Pkg.Doc}}<p class="doc">{{.}}</p>{{end}}
+{{$links := .Links}}
+{{range .Pkg.Files}}{{with .Name}}<h2>{{.}}</h2>{{end}}
+{{range .Symbols}}<div id="{{.Key}}">{{with .Doc}}<p class="doc">{{.}}</p>{{end}}<pre>{{call $links .Signature}}{{annotation .}}</pre></div>
+{{end}}{{end}}
+{{with .Pkg.Examples}}<h2 id="examples">Examples</h2>
+<ul>{{range .}}<li><code>{{.Name}}</code> ({{if .Symbol}}<a href="#{{.Symbol}}">{{.Symbol}}</a>{{else}}package{{end}})</li>{{end}}</ul>
+{{end}}{{with .Pkg.Generate}}<h2 id="generate">go:generate</h2>
+<ul>{{range .}}<li><code>{{.Command}}</code> ({{.File}}:{{.Line}})</li>{{end}}</ul>
+{{end}}</main>
+</body></html>
+{{end}}
+
+{{define "index"}}{{template "head" "Packages"}}
+<main>
+<h1>Packages</h1>
+<ul>{{range .Pages}}<li><a href="{{.File}}">{{.Path}}</a></li>{{end}}</ul>
+</main>
+</body></html>
+{{end}}
+`))
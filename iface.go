@@ -0,0 +1,37 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// runIface prints a Go file declaring, for every "pkg.Type" argument, an
+// interface TypeAPI with the exported methods of *Type, qualified so the
+// file compiles outside the type's package.
+func runIface(cwd string, args []string) {
+	srcs := loadTypeArgs("iface", cwd, args)
+	imports := map[string]string{}
+	var body strings.Builder
+	for _, src := range srcs {
+		if err := mergeImports(imports, src); err != nil {
+			fatal(err)
+		}
+		name := src.typeName + "API"
+		fmt.Fprintf(&body, "// %s is the exported method set of %s.%s.\n", name, src.pkg.Name, src.typeName)
+		fmt.Fprintf(&body, "type %s interface {\n", name)
+		for _, m := range src.methods {
+			fmt.Fprintf(&body, "\t%s(%s)%s\n", m.Name, methodParams(m, false), methodResults(m))
+		}
+		body.WriteString("}\n\n")
+	}
+	printGoSource(genPackageName(srcs), imports, body.String())
+}
+
+// genPackageName returns the --package value, defaulting to the name of
+// the first source package.
+func genPackageName(srcs []*typeSource) string {
+	if *genPackage != "" {
+		return *genPackage
+	}
+	return srcs[0].pkg.Name
+}
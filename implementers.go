@@ -0,0 +1,118 @@
+package main
+
+import (
+	"fmt"
+	"go/importer"
+	"go/token"
+	"go/types"
+
+	"github/urie96/go-list-export/pkg/export"
+)
+
+// typesQuery holds what the go/types based queries share: a config that
+// type-checks packages and an importer common to all of them, so that a
+// type seen through different packages is the same types.Type.
+type typesQuery struct {
+	cfg *export.Config
+	imp types.Importer
+	cwd string
+}
+
+func newTypesQuery(cwd string) *typesQuery {
+	q := &typesQuery{cfg: newConfig(), imp: importer.ForCompiler(token.NewFileSet(), "source", nil), cwd: cwd}
+	q.cfg.Types = true
+	q.cfg.Importer = q.imp
+	return q
+}
+
+// lookupType resolves a "pkg.Type" argument. The package is imported with
+// the shared importer when it can be, so that it is the instance other
+// packages import; local and module cache paths are parsed instead.
+func (q *typesQuery) lookupType(arg string) (*types.TypeName, error) {
+	pkgArg, typeName, ok := splitTypeArg(arg)
+	if !ok {
+		usageError("argument %s is not of the form pkg.Type", arg)
+	}
+	var tpkg *types.Package
+	if !isLocalPath(pkgArg) {
+		tpkg, _ = q.imp.Import(pkgArg)
+	}
+	if tpkg == nil {
+		pkg, err := loadPackage(q.cfg, pkgArg, q.cwd)
+		if pkg == nil {
+			return nil, err
+		}
+		tpkg = pkg.Types()
+	}
+	tn, _ := tpkg.Scope().Lookup(typeName).(*types.TypeName)
+	if tn == nil || !tn.Exported() {
+		return nil, fmt.Errorf("no exported type %s in package %s", typeName, pkgArg)
+	}
+	return tn, nil
+}
+
+// implementer is a type found by the implementers command. Pointer is set
+// when only *Type has the methods.
+type implementer struct {
+	Path    string `json:"path"`
+	Type    string `json:"type"`
+	Pointer bool   `json:"pointer,omitempty"`
+}
+
+// runImplementers prints every exported type of the packages given after
+// the interface argument whose method set, or that of a pointer to it,
+// implements the interface.
+func runImplementers(cwd string, args []string) {
+	if len(args) < 2 {
+		usageError("implementers: usage: implementers pkg.Interface packages...")
+	}
+	q := newTypesQuery(cwd)
+	tn, err := q.lookupType(args[0])
+	if err != nil {
+		fatal(err)
+	}
+	iface, ok := tn.Type().Underlying().(*types.Interface)
+	if !ok {
+		fatal(fmt.Errorf("%s is not an interface", args[0]))
+	}
+
+	found := []implementer{}
+	for _, path := range expandArgs(args[1:], cwd) {
+		pkg, err := loadPackage(q.cfg, path, cwd)
+		if pkg == nil {
+			report(err)
+			continue
+		}
+		if pkg.Types() == nil { // no Go files
+			continue
+		}
+		scope := pkg.Types().Scope()
+		for _, name := range scope.Names() {
+			t, ok := scope.Lookup(name).(*types.TypeName)
+			if !ok || !t.Exported() || t.Type() == tn.Type() {
+				continue
+			}
+			if named, ok := t.Type().(*types.Named); ok && named.TypeParams().Len() > 0 {
+				continue // generic types only implement interfaces once instantiated
+			}
+			switch {
+			case types.Implements(t.Type(), iface):
+				found = append(found, implementer{Path: path, Type: name})
+			case types.Implements(types.NewPointer(t.Type()), iface):
+				found = append(found, implementer{Path: path, Type: name, Pointer: true})
+			}
+		}
+	}
+
+	if *outputFormat == "json" {
+		encodeJSON(found)
+		return
+	}
+	for _, impl := range found {
+		if impl.Pointer {
+			fmt.Printf("*%s.%s\n", impl.Path, impl.Type)
+		} else {
+			fmt.Printf("%s.%s\n", impl.Path, impl.Type)
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github/urie96/go-list-export/pkg/export"
+)
+
+// The symbol index is a SQLite database. It is written and queried with
+// the sqlite3 command-line shell, which keeps the tool free of a database
+// driver in the same way module downloads are left to the go command.
+
+const indexSchema = `CREATE TABLE symbols (
+	module TEXT NOT NULL,
+	version TEXT NOT NULL,
+	package TEXT NOT NULL,
+	file TEXT NOT NULL,
+	kind TEXT NOT NULL,
+	name TEXT NOT NULL,
+	receiver TEXT NOT NULL,
+	key TEXT NOT NULL,
+	signature TEXT NOT NULL,
+	deprecated TEXT NOT NULL
+);
+CREATE INDEX symbols_name ON symbols (name);
+CREATE INDEX symbols_key ON symbols (key);
+`
+
+// indexPath returns the --db value, defaulting to a file in the user's
+// cache directory.
+func indexPath() (string, error) {
+	if *indexDB != "" {
+		return *indexDB, nil
+	}
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "go-list-export", "index.db"), nil
+}
+
+// runIndex writes every exported symbol of every cached module version in
+// GOMODCACHE to the index database, replacing it.
+func runIndex(cwd string, args []string) {
+	if len(args) > 0 {
+		usageError("index: unexpected arguments")
+	}
+	db, err := indexPath()
+	if err != nil {
+		fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Dir(db), 0o777); err != nil {
+		fatal(err)
+	}
+	// Build into a temporary file so that searches keep using the old
+	// index until the new one is complete.
+	tmp := db + ".tmp"
+	os.Remove(tmp)
+	cmd := exec.Command("sqlite3", "-bail", tmp)
+	cmd.Stderr = os.Stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		fatal(err)
+	}
+	if err := cmd.Start(); err != nil {
+		fatal(fmt.Errorf("running sqlite3: %w", err))
+	}
+	w := bufio.NewWriter(stdin)
+	fmt.Fprint(w, indexSchema, "BEGIN;\n")
+
+	cfg := newConfig()
+	pkgs := cachedPackages(cachedModules(true))
+	parsed := make([]*export.Package, len(pkgs))
+	count := 0
+	orderedParallel(len(pkgs), func(i int) {
+		dir := filepath.Join(pkgs[i].Module.Dir, filepath.FromSlash(pkgs[i].Rel))
+		parsed[i], _ = parseDir(cfg, dir) // as for search, broken files are skipped silently
+	}, func(i int) {
+		pkg := parsed[i]
+		parsed[i] = nil
+		if pkg == nil || pkg.Name == "" {
+			return
+		}
+		p := pkgs[i]
+		for _, f := range pkg.Files {
+			for _, sym := range f.Symbols {
+				fmt.Fprintf(w, "INSERT INTO symbols VALUES (%s, %s, %s, %s, %s, %s, %s, %s, %s, %s);\n",
+					sqlQuote(p.Module.Path), sqlQuote(p.Module.Version), sqlQuote(p.importPath()), sqlQuote(f.Name),
+					sqlQuote(string(sym.Kind)), sqlQuote(sym.Name), sqlQuote(sym.Receiver), sqlQuote(sym.Key()), sqlQuote(sym.Signature), sqlQuote(sym.Deprecated))
+				count++
+			}
+		}
+	})
+	fmt.Fprint(w, "COMMIT;\n")
+	if err := w.Flush(); err != nil {
+		fatal(err)
+	}
+	stdin.Close()
+	if err := cmd.Wait(); err != nil {
+		fatal(fmt.Errorf("sqlite3: %w", err))
+	}
+	if err := os.Rename(tmp, db); err != nil {
+		fatal(err)
+	}
+	fmt.Fprintf(os.Stderr, "// indexed %d symbols of %d packages into %s\n", count, len(pkgs), db)
+}
+
+// sqlQuote returns s as an SQL string literal.
+func sqlQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// searchIndex looks up names in the index database, matching them against
+// symbol names and Type.Method keys as searchPackage does.
+func searchIndex(names []string) ([]searchHit, error) {
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = sqlQuote(name)
+	}
+	list := strings.Join(quoted, ", ")
+	return queryIndex(fmt.Sprintf("name IN (%s) OR key IN (%s)", list, list))
+}
+
+// queryIndex returns the symbols of the index database matching an SQL
+// condition.
+func queryIndex(where string) ([]searchHit, error) {
+	db, err := indexPath()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(db); err != nil {
+		return nil, fmt.Errorf("no symbol index at %s; run `go-list-export index` first", db)
+	}
+	query := fmt.Sprintf("SELECT package, version, file, kind, name, receiver, signature, deprecated FROM symbols WHERE %s ORDER BY package, version, file, rowid;", where)
+	out, err := exec.Command("sqlite3", "-json", "-readonly", db, query).Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return nil, fmt.Errorf("sqlite3: %s", strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return nil, fmt.Errorf("running sqlite3: %w", err)
+	}
+	var rows []struct {
+		Package    string
+		Version    string
+		File       string
+		Kind       export.Kind
+		Name       string
+		Receiver   string
+		Signature  string
+		Deprecated string
+	}
+	// sqlite3 prints nothing rather than "[]" when no row matches.
+	if len(strings.TrimSpace(string(out))) > 0 {
+		if err := json.Unmarshal(out, &rows); err != nil {
+			return nil, err
+		}
+	}
+	hits := []searchHit{}
+	for _, r := range rows {
+		sym := &export.Symbol{Kind: r.Kind, Name: r.Name, Receiver: r.Receiver, Signature: r.Signature, Deprecated: r.Deprecated}
+		hits = append(hits, searchHit{Path: r.Package, Version: r.Version, File: r.File, Symbol: sym})
+	}
+	return hits, nil
+}
@@ -0,0 +1,23 @@
+package main
+
+import (
+	"strings"
+
+	"github/urie96/go-list-export/pkg/export"
+)
+
+// addLinks sets the URL of every symbol of pkg to its documentation on
+// pkg.go.dev, pinned to the package's version when it is known. Packages
+// listed by a local path have no import path to link to.
+func addLinks(pkg *export.Package) {
+	if pkg.Path == "" || isLocalPath(pkg.Path) {
+		return
+	}
+	base := "https://pkg.go.dev/" + pkg.Path
+	if pkg.Version != "" && !strings.Contains(pkg.Version, "devel") {
+		base += "@" + pkg.Version
+	}
+	for _, sym := range pkg.Symbols() {
+		sym.URL = base + "#" + sym.Key()
+	}
+}
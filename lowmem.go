@@ -0,0 +1,79 @@
+package main
+
+import (
+	"github/urie96/go-list-export/pkg/export"
+)
+
+// streamText reports whether --low-memory can write the packages listed
+// file by file as they are extracted: the output is text, and no flag
+// needs the whole package at once, as grouping enums or fitting a token
+// budget do. Otherwise --low-memory still parses one file at a time, but
+// the package is collected before it is printed.
+func streamText() bool {
+	return *lowMemory && *outputFormat == "text" && !*groupEnums && !*combined && *maxTokens == 0 &&
+		!*statsAPI && !*statsTokens && !*hashAPI && *outDir == "" && !*excludeDeprecated && stabilitySet == nil
+}
+
+// streamPackage lists the package in dir, whose import path is
+// importPath, writing out each file as soon as it is extracted so that
+// neither the syntax trees nor the symbols of the package pile up in
+// memory. The package doc comes before the first file extracted after it
+// was found. header is as for printPackage; nothing is written for a
+// directory without a package.
+func streamPackage(cfg *export.Config, dir, importPath string, header bool) error {
+	path, version := splitVersion(importPath)
+	if v := versionFromDir(dir); v != "" {
+		version = v
+	}
+	meta := func(name string) *export.Package {
+		return &export.Package{Path: path, Name: name, Module: moduleFromDir(dir), Version: version, Dir: dir}
+	}
+	started, docShown := false, false
+	removed := map[string]bool{}
+	start := func(name string) {
+		if !started && (header || *showHeader) {
+			writeTextHeader(stdout, meta(name))
+		}
+		started = true
+	}
+	pkg, err := cfg.StreamDir(dir, func(pkg *export.Package, f *export.File) {
+		p := meta(pkg.Name)
+		p.Files = []*export.File{f}
+		if !docShown && pkg.Doc != "" {
+			p.Doc, docShown = pkg.Doc, true
+		}
+		keys := []string{}
+		for _, sym := range f.Symbols {
+			keys = append(keys, sym.Key())
+		}
+		preparePackage(p)
+		kept := map[string]bool{}
+		for _, sym := range p.Symbols() {
+			kept[sym.Key()] = true
+		}
+		for _, key := range keys {
+			if !kept[key] {
+				removed[key] = true
+			}
+		}
+		p.Sort(sortOrder)
+		start(pkg.Name)
+		writeTextBody(stdout, p)
+	})
+	if pkg == nil || pkg.Name == "" {
+		return err
+	}
+	start(pkg.Name)
+	tail := meta(pkg.Name)
+	if !docShown {
+		tail.Doc = pkg.Doc
+	}
+	for _, ex := range pkg.Examples {
+		if !removed[ex.Symbol] {
+			tail.Examples = append(tail.Examples, ex)
+		}
+	}
+	tail.Generate = pkg.Generate
+	writeTextBody(stdout, tail)
+	return err
+}
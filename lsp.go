@@ -0,0 +1,106 @@
+package main
+
+import (
+	"io"
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	"github/urie96/go-list-export/pkg/export"
+)
+
+// LSP symbol kinds and tags, from the Language Server Protocol
+// specification.
+const (
+	lspKindClass     = 5
+	lspKindMethod    = 6
+	lspKindInterface = 11
+	lspKindFunction  = 12
+	lspKindVariable  = 13
+	lspKindConstant  = 14
+	lspKindStruct    = 23
+
+	lspTagDeprecated = 1
+)
+
+// lspSymbol is an LSP SymbolInformation.
+type lspSymbol struct {
+	Name          string      `json:"name"`
+	Kind          int         `json:"kind"`
+	Tags          []int       `json:"tags,omitempty"`
+	Location      lspLocation `json:"location"`
+	ContainerName string      `json:"containerName,omitempty"`
+}
+
+type lspLocation struct {
+	URI   string   `json:"uri"`
+	Range lspRange `json:"range"`
+}
+
+type lspRange struct {
+	Start lspPosition `json:"start"`
+	End   lspPosition `json:"end"`
+}
+
+// lspPosition is a zero-based LSP position. Character counts bytes rather
+// than the UTF-16 code units of the specification, which only differ on
+// lines with non-ASCII text before the symbol.
+type lspPosition struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+// writeLSP writes the symbols of pkg as a JSON array of LSP
+// SymbolInformation, ranging from each declared name to the end of its
+// declaration. Methods are contained in their receiver type and other
+// symbols in the package.
+func writeLSP(w io.Writer, pkg *export.Package, header bool) {
+	syms := []*lspSymbol{}
+	for _, f := range pkg.Files {
+		uri := (&url.URL{Scheme: "file", Path: filepath.ToSlash(filepath.Join(pkg.Dir, f.Name))}).String()
+		for _, sym := range f.Symbols {
+			if sym.Pos == nil {
+				continue
+			}
+			s := &lspSymbol{
+				Name:          sym.Name,
+				Kind:          lspKind(sym),
+				Location:      lspLocation{URI: uri, Range: lspRange{lspPos(sym.Pos), lspPos(sym.End)}},
+				ContainerName: pkg.Path,
+			}
+			if sym.Kind == export.KindMethod {
+				s.ContainerName = receiverType(sym)
+			}
+			if sym.Deprecated != "" {
+				s.Tags = []int{lspTagDeprecated}
+			}
+			syms = append(syms, s)
+		}
+	}
+	writeJSONValue(w, syms)
+}
+
+func lspKind(sym *export.Symbol) int {
+	switch sym.Kind {
+	case export.KindFunc:
+		return lspKindFunction
+	case export.KindMethod:
+		return lspKindMethod
+	case export.KindVar:
+		return lspKindVariable
+	case export.KindConst:
+		return lspKindConstant
+	}
+	underlying := strings.TrimPrefix(sym.Signature, "type "+sym.Name+" ")
+	switch {
+	case strings.HasPrefix(underlying, "struct"):
+		return lspKindStruct
+	case strings.HasPrefix(underlying, "interface"):
+		return lspKindInterface
+	}
+	return lspKindClass
+}
+
+func lspPos(p *export.Position) lspPosition {
+	return lspPosition{Line: p.Line - 1, Character: p.Column - 1}
+}
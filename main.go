@@ -1,331 +1,524 @@
 package main
 
 import (
+	"errors"
+	"flag"
 	"fmt"
-	"go/ast"
 	"go/build"
-	"go/parser"
-	"go/token"
+	"io"
 	"io/fs"
 	"os"
+	"os/exec"
 	"path/filepath"
-	"slices"
 	"strings"
-	"unicode"
+
+	"github/urie96/go-list-export/pkg/export"
+)
+
+var (
+	outputFormat        = flag.String("format", "text", "output format: text, json, markdown, yaml, ctags, etags, lsp, apitxt, dot, html, tree, protobuf, textproto, sexp, org, asciidoc, rst or godoc (like go doc -all)")
+	compact             = flag.Bool("compact", false, "collapse struct and interface bodies to struct{} and interface{}")
+	docs                = flag.Bool("docs", false, "print the doc comment of each symbol above its declaration")
+	useTypes            = flag.Bool("types", false, "type-check packages with go/types and print resolved types")
+	goos                = flag.String("goos", "", "only include files built for this GOOS")
+	goarch              = flag.String("goarch", "", "only include files built for this GOARCH")
+	buildTags           = flag.String("tags", "", "comma-separated build tags to satisfy; implies filtering files by build constraints")
+	allPlatforms        = flag.Bool("all-platforms", false, "list symbols of every GOOS/GOARCH and annotate those not available everywhere")
+	excludeDeprecated   = flag.Bool("exclude-deprecated", false, "omit deprecated symbols, and methods of deprecated types")
+	stability           = flag.String("stability", "", "keep only symbols whose doc marks them with one of these comma-separated levels (Stable:, Experimental:, Internal: or //api:level); 'unmarked' keeps those without a marker, and methods default to their type's level")
+	onlyKinds           = flag.String("only", "", "comma-separated kinds of symbols to list: funcs, methods, types, vars, consts")
+	matchPattern        = flag.String("match", "", "only list symbols whose name (or Type.Method for methods) matches this regular expression")
+	excludeMatchPattern = flag.String("exclude-match", "", "omit symbols whose name (or Type.Method for methods) matches this regular expression")
+	download            = flag.Bool("download", false, "download the latest version of modules that are neither resolvable nor in GOMODCACHE")
+	useProxy            = flag.Bool("proxy", false, "fetch non-local, non-standard packages from GOPROXY instead of resolving them locally")
+	jobs                = flag.Int("jobs", 0, "number of files and packages parsed concurrently (default GOMAXPROCS)")
+	watch               = flag.Bool("watch", false, "keep running and print the exported API again whenever a listed package's Go files change")
+	useIndex            = flag.Bool("index", false, "make the search command query the index database instead of parsing GOMODCACHE")
+	fuzzy               = flag.Bool("fuzzy", false, "make the search command rank approximate matches of the query, such as jsonmarsh for json.Marshal")
+	searchLimit         = flag.Int("limit", 20, "number of results of a --fuzzy search (0 for all)")
+	indexDB             = flag.String("db", "", "path of the symbol index database written by the index command (default in the user cache directory)")
+	lowMemory           = flag.Bool("low-memory", false, "parse the files of a package one at a time, keeping a single syntax tree in memory, and with the text format write each file out as soon as it is extracted; slower, and constants defined in terms of constants of other files lose their values")
+	noCache             = flag.Bool("no-cache", false, "parse GOMODCACHE and standard library packages afresh instead of reusing the parse results cached in the user cache directory")
+	maxTokens           = flag.Int("max-tokens", 0, "compact each package's output step by step until it fits in about this many LLM tokens")
+	breakingOnly        = flag.Bool("breaking-only", false, "make diff report only the changes that can break existing users: removals and incompatible signature changes")
+	additionsOnly       = flag.Bool("additions-only", false, "make diff report only the symbols added in the new version")
+	printSchema         = flag.Bool("print-schema", false, "print the JSON Schema of --format json output and exit")
+	statsAPI            = flag.Bool("stats", false, "instead of the exports, print each package's count of funcs, methods, types, vars, consts, generic and deprecated symbols, and files, with totals")
+	statsTokens         = flag.Bool("stats-tokens", false, "instead of the exports, print each package's symbol count and the estimated LLM tokens of its output")
+	genPackage          = flag.String("package", "", "package clause of the code printed by iface and mock (default the name of the type's package)")
+	baseline            = flag.String("baseline", "", "apitxt file the check command compares the current API with")
+	hashAPI             = flag.Bool("hash", false, "instead of the exports, print a SHA-256 digest of the normalized API of each package and of all of them; packages are type-checked, so that the inferred types of vars count")
+	groupEnums          = flag.Bool("enums", false, "list the constants of enum types right after their type, as a const block in text output")
+	positions           = flag.Bool("positions", false, "annotate every declaration with its file.go:line location")
+	links               = flag.Bool("links", false, "link every symbol to its documentation on pkg.go.dev")
+	templateFile        = flag.String("template", "", "print each package with this text/template file, executed with the package as in the JSON output plus .Header")
+	templateText        = flag.String("t", "", "like --template, with the template given inline")
+	htmlDir             = flag.String("html-dir", "", "with --format html, write a page per package and an index.html into this directory instead of printing them")
+	combined            = flag.Bool("combined", false, "present each package as one unit rather than per file: package doc, types with their methods, funcs, vars, consts")
+	examples            = flag.Bool("examples", false, "list the Example functions of the test files and the symbols they demonstrate")
+	goGenerate          = flag.Bool("generate", false, "list the //go:generate directives of each package and the tools they run")
+	xtests              = flag.Bool("xtests", false, "also list the exported helpers of external test packages (pkg_test)")
+	cgoFiles            = flag.String("cgo", "include", "whether to list files that import \"C\": include or skip")
+	showHeader          = flag.Bool("header", false, "announce every package with its module, version and directory, also when listing a single package")
+	maxDepth            = flag.Int("max-depth", -1, "list only the packages at most this many directories below the root of a ... pattern (-1 for no limit)")
+	allPackages         = flag.Bool("all-packages", false, "list every package below each argument, as if it ended in /...")
+	includeInternal     = flag.Bool("include-internal", false, "also list the internal packages matched by patterns, marking their symbols as internal")
+	maxValueLen         = flag.Int("max-value-len", 0, "cut the values of vars and consts longer than this many characters, ending them in ...")
+	noValues            = flag.Bool("no-values", false, "leave out the values of vars and consts")
+	expandLiterals      = flag.Bool("expand-literals", false, "render the elements of composite literals in var values instead of abbreviating them to T{}")
+	outDir              = flag.String("out", "", "write each package to its own file in this directory, named after its import path, instead of printing it")
+	colorMode           = flag.String("color", "auto", "highlight text output: auto (when stdout is a terminal), always or never")
+	skipGenerated       = flag.Bool("skip-generated", false, "leave out the files marked as generated by a \"// Code generated ... DO NOT EDIT.\" comment")
+	markGenerated       = flag.Bool("mark-generated", false, "flag the files marked as generated in file headings, and as \"generated\" in JSON")
+	excludeFiles        = flag.String("exclude", "", "comma-separated glob patterns of file names to skip, such as 'zz_generated*'")
+	excludePkgs         = flag.String("exclude-pkg", "", "comma-separated patterns of import paths to skip when expanding ..., such as '.../proto'")
+	dirArgs             = flag.Bool("dir", false, "take every argument as a directory, relative or absolute, rather than an import path; for local code outside any module")
+	readStdin           = flag.Bool("stdin", false, "also list the package read from stdin, a single Go file or a tar stream; same as the argument -")
+	stdio               = flag.Bool("stdio", false, "serve JSON-RPC \"list\" requests on stdin and stdout, caching parsed packages")
+	sortFlag            = flag.String("sort", "source", "order of the symbols of each file: source, position, name or kind")
+	addr                = flag.String("addr", "localhost:8080", "address the serve command listens on")
+	candidates          = flag.Bool("candidates", false, "list every GOMODCACHE directory matching each argument, preferred first, instead of its exports")
 )
 
+// stdout is where packages are printed. check captures the output here.
+var stdout io.Writer = os.Stdout
+
+// sortOrder is the parsed --sort flag.
+var sortOrder export.SortOrder
+
+// commands maps subcommand names to their implementations. Subcommands
+// share the global flags, which follow the subcommand name.
+var commands = map[string]func(cwd string, args []string){
+	"diff":           runDiff,
+	"bump":           runBump,
+	"serve":          runServe,
+	"search":         runSearch,
+	"index":          runIndex,
+	"iface":          runIface,
+	"mock":           runMock,
+	"check":          runCheck,
+	"implementers":   runImplementers,
+	"satisfies":      runSatisfies,
+	"methodsets":     runMethodSets,
+	"changelog":      runChangelog,
+	"uses-type":      runUsesType,
+	"uses":           runUses,
+	"unused-exports": runUnusedExports,
+	"deprecations":   runDeprecations,
+	"release-notes":  runReleaseNotes,
+	"completion":     runCompletion,
+}
+
 func main() {
-	cwd, err := os.Getwd()
-	if err != nil {
-		panic(err)
+	args := os.Args[1:]
+	if len(args) > 0 && args[0] == "__complete" {
+		// Called by the completion scripts with partial words, which
+		// must not go through flag parsing.
+		runComplete(args[1:])
+		return
 	}
-
-	for _, cmdArg := range os.Args[1:] {
-		packagePath := getPackagePath(cmdArg, cwd)
-		if packagePath == "" {
-			packagePath = searchPackagePathFromGoModCache(cmdArg)
-			fmt.Printf("// `go list` failed, fallback to search GOMODCACHE: %s\n", packagePath)
-		}
-		if packagePath == "" {
-			panic(fmt.Sprintf("module '%s' not found", cmdArg))
+	run := runList
+	if len(args) > 0 {
+		if cmd, ok := commands[args[0]]; ok {
+			run = cmd
+			args = args[1:]
 		}
-		printExported(packagePath)
 	}
-}
+	flag.CommandLine.Parse(args)
+	if *printSchema {
+		os.Stdout.Write(export.Schema)
+		return
+	}
+	if err := loadTemplate(); err != nil {
+		usageError("%v", err)
+	}
+	if formatters[*outputFormat] == nil || *outputFormat == "template" && userTemplate == nil {
+		usageError("unknown format '%s'", *outputFormat)
+	}
+	if err := compileFilters(); err != nil {
+		usageError("%v", err)
+	}
+	order, err := export.ParseSortOrder(*sortFlag)
+	if err != nil {
+		usageError("%v", err)
+	}
+	sortOrder = order
+	if *colorMode != "auto" && *colorMode != "always" && *colorMode != "never" {
+		usageError("--color must be auto, always or never, not '%s'", *colorMode)
+	}
+	if *outputFormat == "text" && useColor() {
+		stdout = &colorWriter{w: os.Stdout}
+	}
+	if *outDir != "" && *outputFormat == "html" && *htmlDir == "" {
+		*htmlDir = *outDir // pages and their index
+	}
+	if *cgoFiles != "include" && *cgoFiles != "skip" {
+		usageError("--cgo must be include or skip, not '%s'", *cgoFiles)
+	}
+	if *lowMemory && *useTypes {
+		usageError("--low-memory cannot be used with --types, which type-checks every file at once")
+	}
+	if *breakingOnly && *additionsOnly {
+		usageError("--breaking-only and --additions-only cannot be used together")
+	}
+	if *combined && (*outputFormat == "ctags" || *outputFormat == "etags" || *outputFormat == "lsp") {
+		usageError("--combined cannot be used with --format %s, which locates symbols by file", *outputFormat)
+	}
 
-func goModCache() string {
-	gomodcache := os.Getenv("GOMODCACHE")
-	if gomodcache != "" {
-		return gomodcache
-	}
-	gopath := build.Default.GOPATH
-	list := filepath.SplitList(gopath)
-	if len(list) == 0 || list[0] == "" {
-		return ""
+	cwd, err := os.Getwd()
+	if err != nil {
+		fatal(err)
 	}
-	return filepath.Join(list[0], "pkg/mod")
+	run(cwd, flag.Args())
+	os.Exit(exitCode)
 }
 
-func searchPackagePathFromGoModCache(importPath string) string {
-	pa := goModCache()
-Loop:
-	for _, name := range strings.Split(importPath, string(os.PathSeparator)) {
-		list, err := os.ReadDir(pa)
-		if err != nil {
-			panic(err)
-		}
-		for _, f := range list {
-			if f.IsDir() && (f.Name() == name || strings.HasPrefix(f.Name(), name+"@")) {
-				pa = filepath.Join(pa, f.Name())
-				continue Loop
-			}
-		}
-		// no this module
-		return ""
+// newConfig returns the extraction config selected by the global flags.
+func newConfig() *export.Config {
+	cfg := &export.Config{
+		Compact:        *compact,
+		OmitParamNames: *outputFormat == "apitxt" || *hashAPI,
+		Docs:           *docs || *outputFormat == "godoc",
+		Positions:      *positions,
+		ExpandLiterals: *expandLiterals,
+		Examples:       *examples,
+		Generate:       *goGenerate,
+		ExternalTests:  *xtests,
+		SkipCgo:        *cgoFiles == "skip",
+		SkipGenerated:  *skipGenerated,
+		Types:          *useTypes || *outputFormat == "apitxt" || *hashAPI,
+		Context:        buildContext(),
+		Jobs:           jobCount(),
+		Incremental:    *watch || *stdio,
+		LowMemory:      *lowMemory,
+	}
+	if *excludeFiles != "" {
+		cfg.ExcludeFiles = strings.Split(*excludeFiles, ",")
 	}
-	return pa
+	if *allPlatforms {
+		cfg.Platforms = knownPlatforms()
+	}
+	return cfg
 }
 
-func getPackagePath(importPath, fromDir string) string {
-	pack, err := build.Default.Import(importPath, fromDir, build.FindOnly)
+// knownPlatforms returns the GOOS/GOARCH pairs supported by the go command
+// in PATH, falling back to the first-class ports if it cannot be run.
+func knownPlatforms() []string {
+	out, err := exec.Command("go", "tool", "dist", "list").Output()
 	if err != nil {
-		return ""
+		return []string{"darwin/amd64", "darwin/arm64", "linux/386", "linux/amd64", "linux/arm", "linux/arm64", "windows/386", "windows/amd64", "windows/arm64"}
 	}
-	return pack.Dir
+	return strings.Fields(string(out))
 }
 
-func printExported(dirpath string) {
-	list, err := os.ReadDir(dirpath)
-	if err != nil {
-		panic(err)
-	}
-	slices.SortFunc(list, func(a, b fs.DirEntry) int {
-		namea := a.Name()
-		nameb := b.Name()
-		if namea == nameb {
-			return 0
-		} else if namea < nameb {
-			return -1
-		} else {
-			return 1
-		}
-	})
-
-	fset := token.NewFileSet()
-	for _, d := range list {
-		if d.IsDir() || !strings.HasSuffix(d.Name(), ".go") || strings.HasSuffix(d.Name(), "_test.go") {
-			continue
-		}
-		filepath := filepath.Join(dirpath, d.Name())
-		if src, err := parser.ParseFile(fset, filepath, nil, 0); err == nil {
-			if src.Name.Name == "main" { // ignore main package
-				continue
-			}
-			printGoFileExport(filepath, src)
-		}
+// buildContext returns the build.Context selected by --goos, --goarch and
+// --tags, or nil if none of them is set. Unset values default to the host
+// platform.
+func buildContext() *build.Context {
+	if *goos == "" && *goarch == "" && *buildTags == "" {
+		return nil
+	}
+	ctx := build.Default
+	if *goos != "" {
+		ctx.GOOS = *goos
+	}
+	if *goarch != "" {
+		ctx.GOARCH = *goarch
 	}
+	if *buildTags != "" {
+		ctx.BuildTags = strings.Split(*buildTags, ",")
+	}
+	// Satisfy the cgo build tag as --cgo says rather than as the
+	// environment does.
+	ctx.CgoEnabled = *cgoFiles == "include"
+	return &ctx
 }
 
-func printGoFileExport(filepath string, f *ast.File) {
-	res := []string{}
-	for _, xdecl := range f.Decls {
-		switch decl := xdecl.(type) {
-		case *ast.FuncDecl:
-			if exported(decl) {
-				res = append(res, formatFuncDecl(decl))
-			}
-		case *ast.GenDecl:
-			s := formatGenDecl(decl)
-			if s != "" {
-				res = append(res, s)
+// runList prints the exported API of every argument.
+func runList(cwd string, args []string) {
+	if *candidates {
+		for _, cmdArg := range args {
+			for _, dir := range goModCacheCandidates(cmdArg) {
+				fmt.Println(dir)
 			}
 		}
+		return
 	}
-	if len(res) == 0 {
+
+	if *readStdin {
+		args = append(args, stdinArg)
+	}
+	cfg := newConfig()
+	if *stdio {
+		runStdio(cfg, cwd)
+		return
+	}
+	if *watch {
+		watchLoop(cfg, cwd, args)
 		return
 	}
-	printFileName(filepath)
-	for _, line := range res {
-		fmt.Println(line)
+	listArgs(cfg, cwd, args)
+	if *statsAPI {
+		printAPIStats()
+	}
+	if *statsTokens {
+		printTokenStats()
+	}
+	if *hashAPI {
+		printAPIHashes()
+	}
+	if *outputFormat == "html" && *htmlDir != "" && !*statsAPI && !*statsTokens && !*hashAPI {
+		writeHTMLIndex()
 	}
-	fmt.Println("")
 }
 
-func formatGenDecl(decl *ast.GenDecl) string {
-	res := []string{}
-	switch decl.Tok {
-	case token.TYPE:
-		for _, spec := range decl.Specs {
-			sp, ok := spec.(*ast.TypeSpec)
-			if ok && isUpper0(sp.Name.Name) {
-				res = append(res, fmt.Sprintf("type %s %s", sp.Name.Name, formatType(sp.Type)))
+// listArgs prints every argument and returns the directories it read,
+// for --watch to monitor.
+func listArgs(cfg *export.Config, cwd string, args []string) []watchTarget {
+	targets := []watchTarget{}
+	files := goFileArgs(args)
+	for _, cmdArg := range args {
+		if isGoFile(cmdArg) {
+			// The files form one package, listed where the first of
+			// them appears.
+			if cmdArg != files[0] {
+				continue
 			}
+			pkg, err := loadFiles(cfg, files, cwd)
+			if err != nil {
+				report(err)
+				if pkg == nil {
+					continue
+				}
+			}
+			targets = append(targets, watchTarget{dir: pkg.Dir})
+			printPackage(pkg, false)
+			continue
 		}
-	case token.VAR, token.CONST:
-		key := "var"
-		if decl.Tok == token.CONST {
-			key = "const"
+		if *allPackages {
+			cmdArg = allPackagesPattern(cmdArg)
 		}
-		for _, spec := range decl.Specs {
-			sp, ok := spec.(*ast.ValueSpec)
-			if !ok {
-				continue
+		if isPattern(cmdArg) {
+			if rootDir := listPattern(cfg, cmdArg, cwd); rootDir != "" {
+				targets = append(targets, watchTarget{dir: rootDir, recursive: true})
 			}
-			typ := formatType(sp.Type)
-			if typ != "" {
-				typ += " "
-			}
-			for i, name := range sp.Names {
-				if isUpper0(name.Name) {
-					s := fmt.Sprintf("%s %s %s", key, name, typ)
-					if len(sp.Values) > i {
-						s += "= "
-						s += formatType(sp.Values[i])
-					}
-					res = append(res, s)
+			continue
+		}
+		if streamText() && !*useProxy && cmdArg != stdinArg {
+			if dir, err := resolveDir(cmdArg, cwd); err == nil {
+				if err := streamPackage(cfg, dir, cmdArg, false); err != nil {
+					report(err)
 				}
+				targets = append(targets, watchTarget{dir: dir})
+				continue
 			}
 		}
+		pkg, err := loadPackage(cfg, cmdArg, cwd)
+		if err != nil {
+			report(err)
+			if pkg == nil {
+				continue
+			}
+		}
+		targets = append(targets, watchTarget{dir: pkg.Dir})
+		printPackage(pkg, false)
 	}
-	return strings.Join(res, "\n")
+	return targets
 }
 
-func printFileName(path string) {
-	fmt.Printf("// %s:\n", filepath.Base(path))
+// listPattern prints every package matching a "..." pattern and returns
+// the directory the pattern is rooted at. Errors are reported per package
+// and do not stop the walk.
+func listPattern(cfg *export.Config, pattern, cwd string) string {
+	root := patternRoot(pattern)
+	rootDir, err := resolveOrDownloadDir(root, cwd)
+	if err != nil {
+		report(err)
+		return ""
+	}
+	rels, err := walkPackageDirs(rootDir, *maxDepth)
+	if err != nil {
+		report(err)
+	}
+	rels = dropExcluded(root, dropInternal(rels))
+	if streamText() {
+		for _, rel := range rels {
+			if err := streamPackage(cfg, filepath.Join(rootDir, filepath.FromSlash(rel)), joinImportPath(root, rel), true); err != nil {
+				report(err)
+			}
+		}
+		return rootDir
+	}
+	pkgs := make([]*export.Package, len(rels))
+	errs := make([]error, len(rels))
+	orderedParallel(len(rels), func(i int) {
+		pkgs[i], errs[i] = parseDir(cfg, filepath.Join(rootDir, filepath.FromSlash(rels[i])))
+	}, func(i int) {
+		pkg := pkgs[i]
+		pkgs[i] = nil // printed packages need not stay in memory
+		if errs[i] != nil {
+			report(errs[i])
+			if pkg == nil {
+				return
+			}
+		}
+		if pkg.Name == "" { // no Go files, or only a main package
+			return
+		}
+		pkg.Path = joinImportPath(root, rels[i])
+		pkg.Module = moduleFromDir(pkg.Dir)
+		pkg.Version = versionFromDir(pkg.Dir)
+		printPackage(pkg, true)
+	})
+	return rootDir
 }
 
-func formatFuncDecl(decl *ast.FuncDecl) string {
-	s := "func "
-	if decl.Recv != nil {
-		if len(decl.Recv.List) != 1 {
-			return fmt.Sprintf("strange receiver for %s: %#v", decl.Name.Name, decl.Recv)
+// loadPackage resolves a single (non-pattern) argument and parses it. As
+// with ParseDir, a package may be returned together with a parse error.
+func loadPackage(cfg *export.Config, cmdArg, cwd string) (*export.Package, error) {
+	if cmdArg == stdinArg {
+		return loadStdin(cfg)
+	}
+	if pkgArg, key, ok := splitSymbolArg(cmdArg, cwd); ok {
+		pkg, err := loadPackage(cfg, pkgArg, cwd)
+		if pkg == nil {
+			return nil, err
 		}
-		field := decl.Recv.List[0]
-		if len(field.Names) == 0 {
-			// function definition in interface (ignore)
-			return ""
-		} else if len(field.Names) != 1 {
-			return fmt.Sprintf("strange receiver field for %s: %#v", decl.Name.Name, field)
+		pkg.Filter(func(sym *export.Symbol) bool { return sym.Key() == key })
+		pkg.Doc = ""
+		if len(pkg.Symbols()) == 0 {
+			return nil, &notFoundError{arg: cmdArg, reason: fmt.Sprintf("no exported symbol %s in package %s", key, pkgArg)}
 		}
-		s += fmt.Sprintf("(%s %s) ", field.Names[0], formatType(field.Type))
+		return pkg, err
+	}
+	if *useProxy && !*dirArgs && !isLocalPath(cmdArg) && localDir(cmdArg, cwd) == "" && stdPackageDir(cmdArg) == "" {
+		return loadFromProxy(cfg, cmdArg)
+	}
+	packagePath, err := resolveDir(cmdArg, cwd)
+	var nf *notFoundError
+	if errors.As(err, &nf) && !*dirArgs && stdPackageDir(cmdArg) == "" {
+		// Before downloading, look for the module zip in GOMODCACHE's
+		// download cache, which may exist without the extracted tree.
+		if pkg, err := loadFromCacheZip(cfg, cmdArg); pkg != nil || err != nil {
+			return pkg, err
+		}
+		packagePath, err = downloadDir(cmdArg)
+	}
+	if err != nil {
+		return nil, err
 	}
-	s += decl.Name.Name
-	if decl.Type.TypeParams != nil {
-		s += fmt.Sprintf("[%s]", formatFields(decl.Type.TypeParams))
+	pkg, err := parseDir(cfg, packagePath)
+	if pkg == nil {
+		return nil, err
 	}
-	s += fmt.Sprintf("(%s)", formatFields(decl.Type.Params))
-	s += formatFuncResults(decl.Type.Results)
-	return s
+	pkg.Path, pkg.Version = splitVersion(cmdArg)
+	pkg.Module = moduleFromDir(packagePath)
+	if v := versionFromDir(packagePath); v != "" {
+		pkg.Version = v
+	}
+	return pkg, err
 }
 
-func formatFields(fields *ast.FieldList) string {
-	s := ""
-	for i, field := range fields.List {
-		for j, name := range field.Names {
-			s += name.Name
-			if j != len(field.Names)-1 {
-				s += ","
-			}
-			s += " "
+// preparePackage applies the flags that act on each symbol by itself:
+// the filters, the limit on values, the marking of internal packages and
+// the documentation links.
+func preparePackage(pkg *export.Package) {
+	filterPackage(pkg)
+	limitValues(pkg)
+	if *includeInternal && isInternalPath(pkg.Path) {
+		for _, sym := range pkg.Symbols() {
+			sym.Internal = true
 		}
-		s += formatType(field.Type)
-		if i != len(fields.List)-1 {
-			s += ", "
+	}
+	if *links {
+		addLinks(pkg)
+	}
+	if !*markGenerated {
+		for _, f := range pkg.Files {
+			f.Generated = false
 		}
 	}
-	return s
 }
 
-func formatType(typ ast.Expr) string {
-	switch t := typ.(type) {
-	case nil:
-		return ""
-	case *ast.Ident:
-		return t.Name
-	case *ast.SelectorExpr:
-		return fmt.Sprintf("%s.%s", formatType(t.X), t.Sel.Name)
-	case *ast.StarExpr:
-		return fmt.Sprintf("*%s", formatType(t.X))
-	case *ast.ArrayType:
-		return fmt.Sprintf("[%s]%s", formatType(t.Len), formatType(t.Elt))
-	case *ast.Ellipsis:
-		return "..." + formatType(t.Elt)
-	case *ast.FuncType:
-		return fmt.Sprintf("func(%s)%s", formatFields(t.Params), formatFuncResults(t.Results))
-	case *ast.MapType:
-		return fmt.Sprintf("map[%s]%s", formatType(t.Key), formatType(t.Value))
-	case *ast.ChanType:
-		s := ""
-		if t.Dir == 1 {
-			s = "<-chan"
-		} else if t.Dir == 2 {
-			s = "chan<-"
-		} else if t.Dir == 3 {
-			s = "chan"
-		}
-		return fmt.Sprintf("%s %s", s, formatType(t.Value))
-	case *ast.BasicLit:
-		return t.Value
-	case *ast.StructType:
-		return "struct{}"
-	case *ast.InterfaceType:
-		return "interface{}"
-	case *ast.UnaryExpr:
-		return t.Op.String() + formatType(t.X)
-	case *ast.CompositeLit:
-		// abandon fields in {}
-		return formatType(t.Type) + "{}"
-	case *ast.CallExpr:
-		return formatType(t.Fun) + "()"
-	case *ast.BinaryExpr:
-		return fmt.Sprintf("%s %s %s", formatType(t.X), t.Op.String(), formatType(t.Y))
-	case *ast.FuncLit:
-		return formatType(t.Type)
-	case *ast.IndexExpr:
-		return fmt.Sprintf("%s[%s]", formatType(t.X), formatType(t.Index))
-	case *ast.IndexListExpr:
-		typ := []string{}
-		for _, expr := range t.Indices {
-			typ = append(typ, formatType(expr))
-		}
-		return fmt.Sprintf("%s[%s]", formatType(t.X), strings.Join(typ, ", "))
-	case *ast.ParenExpr:
-		return fmt.Sprintf("(%s)", formatType(t.X))
-	case *ast.SliceExpr:
-		s := formatType(t.X)
-		s += "["
-		if t.Low != nil {
-			s += formatType(t.Low)
-		}
-		s += ":"
-		if t.High != nil {
-			s += formatType(t.High)
-		}
-		if t.Slice3 {
-			s += ":"
-		}
-		if t.Max != nil {
-			s += formatType(t.Max)
-		}
-		s += "]"
-		return s
-	case *ast.TypeAssertExpr:
-		return fmt.Sprintf("%s.(%s)", formatType(t.X), formatType(t.Type))
-	default:
-		return fmt.Sprintf("unsupported type %#v", t)
+// arrangePackage groups, orders and combines the symbols of pkg as the
+// --enums, --sort and --combined flags ask.
+func arrangePackage(pkg *export.Package) {
+	if *groupEnums {
+		pkg.GroupEnums()
+	}
+	pkg.Sort(sortOrder)
+	if *combined {
+		pkg.Combine()
 	}
 }
 
-func formatFuncResults(fields *ast.FieldList) string {
-	s := ""
-	if fields != nil {
-		s += " "
-		needPar := len(fields.List) > 1 || (len(fields.List) == 1 && len(fields.List[0].Names) > 0)
-		if needPar {
-			s += "("
-		}
-		s += formatFields(fields)
-		if needPar {
-			s += ")"
+// isGoFile reports whether arg names a Go source file rather than a
+// package.
+func isGoFile(arg string) bool {
+	return strings.HasSuffix(arg, ".go") && !strings.Contains(arg, "@")
+}
+
+// goFileArgs returns the arguments naming Go files.
+func goFileArgs(args []string) []string {
+	files := []string{}
+	for _, arg := range args {
+		if isGoFile(arg) {
+			files = append(files, arg)
 		}
 	}
-	return s
+	return files
 }
 
-func isUpper0(s string) bool {
-	if strings.HasPrefix(s, "*") {
-		return unicode.IsUpper([]rune(s)[1])
+// loadFiles parses the named Go files, relative to cwd, as one package.
+// The package takes its path from the files' directory as given.
+func loadFiles(cfg *export.Config, files []string, cwd string) (*export.Package, error) {
+	abs := make([]string, len(files))
+	for i, name := range files {
+		abs[i] = name
+		if !filepath.IsAbs(name) {
+			abs[i] = filepath.Join(cwd, name)
+		}
+	}
+	pkg, err := cfg.ParseFiles(abs...)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, &notFoundError{arg: strings.Join(files, " "), reason: err.Error()}
 	}
-	return unicode.IsUpper([]rune(s)[0])
+	if pkg == nil {
+		return nil, err
+	}
+	pkg.Path = filepath.Dir(files[0])
+	pkg.Module = moduleFromDir(pkg.Dir)
+	return pkg, err
 }
 
-func exported(decl *ast.FuncDecl) bool {
-	if decl.Recv != nil {
-		if len(decl.Recv.List) != 1 {
-			panic(fmt.Errorf("strange receiver for %s: %#v", decl.Name.Name, decl.Recv))
-		}
-		field := decl.Recv.List[0]
-		return isUpper0(formatType(field.Type)) && isUpper0(decl.Name.Name)
+// printPackage writes pkg to stdout in the selected output format. header
+// asks the format to announce the package before its files, which matters
+// when several packages are printed one after another.
+func printPackage(pkg *export.Package, header bool) {
+	preparePackage(pkg)
+	arrangePackage(pkg)
+	if *maxTokens > 0 {
+		pkg = fitTokens(pkg, header)
+	}
+	if *statsAPI {
+		addAPIStats(pkg)
+		return
+	}
+	if *statsTokens {
+		addTokenStats(pkg, header)
+		return
+	}
+	if *hashAPI {
+		addAPIHash(pkg, header)
+		return
+	}
+	if *outputFormat == "html" && *htmlDir != "" {
+		writeHTMLPage(pkg, header)
+		return
+	}
+	if *outDir != "" {
+		writePackageFile(pkg, header)
+		return
 	}
-	return isUpper0(decl.Name.Name)
+	formatters[*outputFormat](stdout, pkg, header)
 }
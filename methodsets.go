@@ -0,0 +1,143 @@
+package main
+
+import (
+	"fmt"
+	"go/types"
+	"sort"
+	"strings"
+)
+
+// methodSetSummary is the method set of a type as reported by the
+// methodsets command. Value lists the exported methods of T and Pointer
+// those that only *T has; Implements and PointerImplements split the
+// interfaces checked likewise.
+type methodSetSummary struct {
+	Path              string   `json:"path"`
+	Type              string   `json:"type"`
+	Value             []string `json:"value"`
+	Pointer           []string `json:"pointer"`
+	Implements        []string `json:"implements,omitempty"`
+	PointerImplements []string `json:"pointer_implements,omitempty"`
+}
+
+// namedInterface is an interface checked by the methodsets command.
+type namedInterface struct {
+	name  string
+	iface *types.Interface
+}
+
+// runMethodSets prints, for every exported non-interface type of the
+// packages, which exported methods are declared on (or promoted to) T and
+// which only *T has, and which interfaces each form therefore satisfies:
+// error, the well-known interfaces of satisfies and the interfaces the
+// packages declare themselves.
+func runMethodSets(cwd string, args []string) {
+	if len(args) == 0 {
+		usageError("methodsets: usage: methodsets packages...")
+	}
+	q := newTypesQuery(cwd)
+	ifaces := []namedInterface{{"error", types.Universe.Lookup("error").Type().Underlying().(*types.Interface)}}
+	for _, arg := range wellKnownInterfaces {
+		if tn, err := q.lookupType(arg); err == nil {
+			ifaces = append(ifaces, namedInterface{arg, tn.Type().Underlying().(*types.Interface)})
+		}
+	}
+
+	type typeOf struct {
+		path string
+		tn   *types.TypeName
+	}
+	var typeNames []typeOf
+	for _, path := range expandArgs(args, cwd) {
+		pkg, err := loadPackage(q.cfg, path, cwd)
+		if pkg == nil {
+			report(err)
+			continue
+		}
+		if pkg.Types() == nil { // no Go files
+			continue
+		}
+		scope := pkg.Types().Scope()
+		for _, name := range scope.Names() {
+			tn, ok := scope.Lookup(name).(*types.TypeName)
+			if !ok || !tn.Exported() || tn.IsAlias() {
+				continue
+			}
+			if named, ok := tn.Type().(*types.Named); ok && named.TypeParams().Len() > 0 {
+				continue // generic types only have method sets once instantiated
+			}
+			if iface, ok := tn.Type().Underlying().(*types.Interface); ok {
+				if iface.NumMethods() > 0 {
+					ifaces = append(ifaces, namedInterface{path + "." + name, iface})
+				}
+				continue
+			}
+			typeNames = append(typeNames, typeOf{path, tn})
+		}
+	}
+
+	found := []methodSetSummary{}
+	for _, t := range typeNames {
+		s := methodSetSummary{Path: t.path, Type: t.tn.Name(), Value: []string{}, Pointer: []string{}}
+		value := exportedMethods(t.tn.Type())
+		for _, name := range exportedMethods(types.NewPointer(t.tn.Type())) {
+			if i := sort.SearchStrings(value, name); i < len(value) && value[i] == name {
+				s.Value = append(s.Value, name)
+			} else {
+				s.Pointer = append(s.Pointer, name)
+			}
+		}
+		if len(s.Value) == 0 && len(s.Pointer) == 0 {
+			continue
+		}
+		for _, i := range ifaces {
+			switch {
+			case types.Implements(t.tn.Type(), i.iface):
+				s.Implements = append(s.Implements, i.name)
+			case types.Implements(types.NewPointer(t.tn.Type()), i.iface):
+				s.PointerImplements = append(s.PointerImplements, i.name)
+			}
+		}
+		found = append(found, s)
+	}
+
+	if *outputFormat == "json" {
+		encodeJSON(found)
+		return
+	}
+	for i, s := range found {
+		if i > 0 {
+			fmt.Println()
+		}
+		fmt.Printf("%s.%s\n", s.Path, s.Type)
+		fmt.Printf("\t%s: %s\n", s.Type, listOrNone(s.Value))
+		fmt.Printf("\t*%s only: %s\n", s.Type, listOrNone(s.Pointer))
+		if len(s.Implements) > 0 {
+			fmt.Printf("\t%s implements: %s\n", s.Type, strings.Join(s.Implements, ", "))
+		}
+		if len(s.PointerImplements) > 0 {
+			fmt.Printf("\t*%s only implements: %s\n", s.Type, strings.Join(s.PointerImplements, ", "))
+		}
+	}
+}
+
+// exportedMethods returns the names of the exported methods in the method
+// set of t, sorted.
+func exportedMethods(t types.Type) []string {
+	names := []string{}
+	mset := types.NewMethodSet(t)
+	for i := 0; i < mset.Len(); i++ {
+		if obj := mset.At(i).Obj(); obj.Exported() {
+			names = append(names, obj.Name())
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func listOrNone(names []string) string {
+	if len(names) == 0 {
+		return "none"
+	}
+	return strings.Join(names, ", ")
+}
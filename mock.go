@@ -0,0 +1,99 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github/urie96/go-list-export/pkg/export"
+)
+
+// runMock prints a Go file declaring, for every "pkg.Interface" argument,
+// a struct InterfaceMock implementing the interface. Each method calls the
+// struct's func field of the same name plus "Func" if it is set, and
+// otherwise returns zero values.
+func runMock(cwd string, args []string) {
+	srcs := loadTypeArgs("mock", cwd, args)
+	imports := map[string]string{}
+	var body strings.Builder
+	for _, src := range srcs {
+		if err := checkMockable(src); err != nil {
+			fatal(err)
+		}
+		if err := mergeImports(imports, src); err != nil {
+			fatal(err)
+		}
+		writeMock(&body, src)
+	}
+	printGoSource(genPackageName(srcs), imports, body.String())
+}
+
+// checkMockable reports why the type of src cannot be implemented by a
+// struct of another package, if it cannot.
+func checkMockable(src *typeSource) error {
+	for _, sym := range src.pkg.Symbols() {
+		if sym.Kind != export.KindType || sym.Name != src.typeName {
+			continue
+		}
+		if !strings.HasPrefix(sym.Signature, "type "+sym.Name+" interface") {
+			return fmt.Errorf("%s.%s is not an interface", src.pkg.Path, src.typeName)
+		}
+		if strings.Contains(sym.Signature, "unexported methods") {
+			return fmt.Errorf("%s.%s has unexported methods and cannot be implemented outside its package", src.pkg.Path, src.typeName)
+		}
+	}
+	return nil
+}
+
+func writeMock(w *strings.Builder, src *typeSource) {
+	name := src.typeName + "Mock"
+	fmt.Fprintf(w, "// %s implements %s.%s.\n", name, src.pkg.Name, src.typeName)
+	fmt.Fprintf(w, "// Its methods call the func field named after them, if set, and return\n// zero values otherwise.\n")
+	fmt.Fprintf(w, "type %s struct {\n", name)
+	for _, m := range src.methods {
+		fmt.Fprintf(w, "\t%sFunc func(%s)%s\n", m.Name, methodParams(m, false), methodResults(m))
+	}
+	w.WriteString("}\n\n")
+
+	for _, m := range src.methods {
+		params := methodParams(m, true)
+		recv := "m"
+		if strings.HasPrefix(params, "m ") || strings.Contains(params, ", m ") {
+			recv = "mock"
+		}
+		// Named results let a bare return produce the zero values.
+		results := []string{}
+		for i, r := range m.Results {
+			rname := r.Name
+			if rname == "" || rname == "_" {
+				rname = fmt.Sprintf("r%d", i)
+			}
+			results = append(results, rname+" "+r.Type)
+		}
+		resultList := ""
+		if len(results) > 0 {
+			resultList = " (" + strings.Join(results, ", ") + ")"
+		}
+		args := []string{}
+		for i, p := range m.Params {
+			arg := p.Name
+			if arg == "" || arg == "_" {
+				arg = fmt.Sprintf("p%d", i)
+			}
+			if m.Variadic && i == len(m.Params)-1 {
+				arg += "..."
+			}
+			args = append(args, arg)
+		}
+		call := fmt.Sprintf("%s.%sFunc(%s)", recv, m.Name, strings.Join(args, ", "))
+
+		fmt.Fprintf(w, "func (%s *%s) %s(%s)%s {\n", recv, name, m.Name, params, resultList)
+		fmt.Fprintf(w, "\tif %s.%sFunc != nil {\n", recv, m.Name)
+		if len(m.Results) > 0 {
+			fmt.Fprintf(w, "\t\treturn %s\n", call)
+		} else {
+			fmt.Fprintf(w, "\t\t%s\n\t\treturn\n", call)
+		}
+		w.WriteString("\t}\n")
+		w.WriteString("\treturn\n}\n\n")
+	}
+}
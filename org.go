@@ -0,0 +1,116 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github/urie96/go-list-export/pkg/export"
+)
+
+// writeOrg writes pkg as an Org document: a headline for the package, a
+// sub-headline for its constants, variables and functions each, and one
+// per type holding the type with its enum constants and methods.
+// Signatures go into source blocks and doc comments become paragraphs
+// between them, as in markdown output.
+func writeOrg(w io.Writer, pkg *export.Package, header bool) {
+	fmt.Fprintf(w, "* package %s", pkg.Path)
+	if pkg.Version != "" {
+		fmt.Fprintf(w, " (%s)", pkg.Version)
+	}
+	fmt.Fprint(w, "\n")
+	if pkg.Module != "" {
+		fmt.Fprintf(w, "Module =%s=, read from =%s=.\n", pkg.Module, pkg.Dir)
+	}
+	if pkg.Doc != "" {
+		fmt.Fprintf(w, "\n%s\n", orgText(pkg.Doc))
+	}
+
+	var consts, vars, funcs []*export.Symbol
+	types := []string{}
+	byType := map[string][]*export.Symbol{}
+	for _, sym := range pkg.Symbols() {
+		switch {
+		case sym.Kind == export.KindType:
+			types = append(types, sym.Name)
+			byType[sym.Name] = append([]*export.Symbol{sym}, byType[sym.Name]...)
+		case sym.Kind == export.KindMethod:
+			byType[receiverType(sym)] = append(byType[receiverType(sym)], sym)
+		case sym.Enum != "":
+			byType[sym.Enum] = append(byType[sym.Enum], sym)
+		case sym.Kind == export.KindConst:
+			consts = append(consts, sym)
+		case sym.Kind == export.KindVar:
+			vars = append(vars, sym)
+		default:
+			funcs = append(funcs, sym)
+		}
+	}
+	writeOrgSection(w, "Constants", consts)
+	writeOrgSection(w, "Variables", vars)
+	writeOrgSection(w, "Functions", funcs)
+	for _, name := range types {
+		writeOrgSection(w, "type "+name, byType[name])
+		delete(byType, name)
+	}
+	rest := []string{} // types declared elsewhere, with methods here
+	for name := range byType {
+		rest = append(rest, name)
+	}
+	sort.Strings(rest)
+	for _, name := range rest {
+		writeOrgSection(w, "type "+name, byType[name])
+	}
+	if len(pkg.Examples) > 0 {
+		fmt.Fprint(w, "\n** Examples\n")
+		for _, ex := range pkg.Examples {
+			fmt.Fprintf(w, "- =%s= (%s)\n", ex.Name, exampleTarget(ex))
+		}
+	}
+	if len(pkg.Generate) > 0 {
+		fmt.Fprint(w, "\n** go:generate\n")
+		for _, d := range pkg.Generate {
+			fmt.Fprintf(w, "- =%s= (%s:%d)\n", d.Command, d.File, d.Line)
+		}
+	}
+}
+
+// writeOrgSection writes a second-level headline followed by syms.
+// Consecutive undocumented symbols share a source block.
+func writeOrgSection(w io.Writer, title string, syms []*export.Symbol) {
+	if len(syms) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "\n** %s\n", title)
+	inBlock := false
+	for _, sym := range syms {
+		if sym.Doc != "" {
+			if inBlock {
+				fmt.Fprintln(w, "#+end_src")
+				inBlock = false
+			}
+			fmt.Fprintf(w, "%s\n", orgText(sym.Doc))
+		}
+		if !inBlock {
+			fmt.Fprintln(w, "#+begin_src go")
+			inBlock = true
+		}
+		fmt.Fprintln(w, sym.Signature+annotation(sym))
+	}
+	if inBlock {
+		fmt.Fprintln(w, "#+end_src")
+	}
+}
+
+// orgText indents the lines of a doc comment that Org would otherwise
+// take for headlines or keywords.
+func orgText(s string) string {
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		if strings.HasPrefix(line, "*") || strings.HasPrefix(line, "#+") {
+			lines[i] = " " + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+
+	"github/urie96/go-list-export/pkg/export"
+)
+
+// outExts maps output formats to the extension of the files written with
+// --out.
+var outExts = map[string]string{
+	"text":      ".txt",
+	"json":      ".json",
+	"markdown":  ".md",
+	"yaml":      ".yaml",
+	"ctags":     ".tags",
+	"etags":     ".TAGS",
+	"lsp":       ".json",
+	"apitxt":    ".txt",
+	"dot":       ".dot",
+	"tree":      ".txt",
+	"template":  ".txt",
+	"protobuf":  ".pb",
+	"textproto": ".textproto",
+	"sexp":      ".el",
+	"org":       ".org",
+	"asciidoc":  ".adoc",
+	"rst":       ".rst",
+	"godoc":     ".txt",
+}
+
+// writePackageFile writes pkg in the selected format to its own file in
+// the --out directory, named after its import path. Each file stands on
+// its own, so formats that keep state across packages start afresh.
+func writePackageFile(pkg *export.Package, header bool) {
+	ctagsHeaderDone = false
+	treeStack = nil
+	if err := os.MkdirAll(*outDir, 0o755); err != nil {
+		report(err)
+		return
+	}
+	f, err := os.Create(filepath.Join(*outDir, packageFileName(pkg.Path)+outExts[*outputFormat]))
+	if err != nil {
+		report(err)
+		return
+	}
+	formatters[*outputFormat](f, pkg, header)
+	if err := f.Close(); err != nil {
+		report(err)
+	}
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"runtime"
+	"sync"
+)
+
+// jobCount returns the --jobs value, defaulting to runtime.GOMAXPROCS(0).
+func jobCount() int {
+	if *jobs > 0 {
+		return *jobs
+	}
+	return runtime.GOMAXPROCS(0)
+}
+
+// orderedParallel calls work(i) for every i in [0, n) with at most
+// jobCount() calls running at a time, and calls emit(i) in index order as
+// soon as work(i) and all earlier emits are done. This keeps the output
+// deterministic while it streams.
+func orderedParallel(n int, work, emit func(i int)) {
+	done := make([]chan struct{}, n)
+	for i := range done {
+		done[i] = make(chan struct{})
+	}
+	sem := make(chan struct{}, jobCount())
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < n; i++ {
+			sem <- struct{}{}
+			go func(i int) {
+				defer func() { <-sem; close(done[i]) }()
+				work(i)
+			}(i)
+		}
+	}()
+	for i := 0; i < n; i++ {
+		<-done[i]
+		emit(i)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/gob"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github/urie96/go-list-export/pkg/export"
+)
+
+// parseCacheDir returns the directory of the parse cache, or "" if there
+// is no user cache directory.
+var parseCacheDir = sync.OnceValue(func() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "go-list-export", "parse")
+})
+
+// executableStamp identifies the running binary, so that the cache of a
+// build whose output may differ is not reused after an upgrade.
+var executableStamp = sync.OnceValue(func() string {
+	exe, err := os.Executable()
+	if err != nil {
+		return ""
+	}
+	fi, err := os.Stat(exe)
+	if err != nil {
+		return ""
+	}
+	return fmt.Sprintf("%s %d %d", exe, fi.Size(), fi.ModTime().UnixNano())
+})
+
+// incremental holds the last package parsed from each directory with
+// Config.Incremental, which the watch, stdio and serve modes set, for
+// parseDir to reparse.
+var incremental = struct {
+	sync.Mutex
+	pkgs map[string]*export.Package
+}{pkgs: map[string]*export.Package{}}
+
+// parseDir is cfg.ParseDir with a persistent cache for directories whose
+// contents never change: module versions extracted in GOMODCACHE, and
+// released standard libraries. Results are keyed by the directory, the
+// options of cfg that affect parsing and the running binary, and stored
+// gob-encoded under the user cache directory. Packages that failed to
+// parse completely are not cached, nor are type-checked ones, whose
+// go/types package cannot be stored. --no-cache parses afresh.
+//
+// Other directories parsed with Config.Incremental are reparsed from the
+// package last parsed from them, so that only their changed files are
+// read again; the caller gets a copy it may modify.
+func parseDir(cfg *export.Config, dir string) (*export.Package, error) {
+	key := parseCacheKey(cfg, dir)
+	if key == "" && cfg.Incremental {
+		incremental.Lock()
+		prev := incremental.pkgs[dir]
+		incremental.Unlock()
+		var pkg *export.Package
+		var err error
+		if prev != nil {
+			pkg, err = cfg.Reparse(prev)
+		} else {
+			pkg, err = cfg.ParseDir(dir)
+		}
+		if pkg == nil {
+			return nil, err
+		}
+		incremental.Lock()
+		incremental.pkgs[dir] = pkg
+		incremental.Unlock()
+		return pkg.Clone(), err
+	}
+	if key == "" {
+		return cfg.ParseDir(dir)
+	}
+	file := filepath.Join(parseCacheDir(), key[:2], key)
+	if data, err := os.ReadFile(file); err == nil {
+		pkg := &export.Package{}
+		if gob.NewDecoder(bytes.NewReader(data)).Decode(pkg) == nil {
+			// gob leaves empty slices out, which JSON output tells
+			// apart from nil ones.
+			if pkg.Files == nil {
+				pkg.Files = []*export.File{}
+			}
+			for _, f := range pkg.Files {
+				if f.Symbols == nil {
+					f.Symbols = []*export.Symbol{}
+				}
+			}
+			return pkg, nil
+		}
+	}
+	pkg, err := cfg.ParseDir(dir)
+	if err != nil || pkg == nil {
+		return pkg, err
+	}
+	var buf bytes.Buffer
+	if gob.NewEncoder(&buf).Encode(pkg) == nil && os.MkdirAll(filepath.Dir(file), 0o777) == nil {
+		// Write to a temporary file first so that a concurrent reader
+		// never sees a partial entry.
+		if tmp, err := os.CreateTemp(filepath.Dir(file), key+".*"); err == nil {
+			_, werr := tmp.Write(buf.Bytes())
+			if cerr := tmp.Close(); werr != nil || cerr != nil || os.Rename(tmp.Name(), file) != nil {
+				os.Remove(tmp.Name())
+			}
+		}
+	}
+	return pkg, nil
+}
+
+// parseCacheKey returns the hex-encoded cache key of parsing dir with cfg,
+// or "" if the result must not be cached. The key of a GOROOT directory
+// includes the Go release.
+func parseCacheKey(cfg *export.Config, dir string) string {
+	if *noCache || cfg.Types || parseCacheDir() == "" || !immutableDir(dir) {
+		return ""
+	}
+	opts := struct {
+		Dir, Binary, GoVersion                        string
+		Compact, OmitParamNames, Docs, ExpandLiterals bool
+		Positions, Examples, ExternalTests, SkipCgo   bool
+		SkipGenerated, LowMemory, Generate            bool
+		ExcludeFiles, Platforms                       []string
+		GOOS, GOARCH                                  string
+		BuildTags, ToolTags, ReleaseTags              []string
+		CgoEnabled, Context                           bool
+	}{
+		Dir: dir, Binary: executableStamp(),
+		Compact: cfg.Compact, OmitParamNames: cfg.OmitParamNames, Docs: cfg.Docs, ExpandLiterals: cfg.ExpandLiterals,
+		Positions: cfg.Positions, Examples: cfg.Examples, ExternalTests: cfg.ExternalTests, SkipCgo: cfg.SkipCgo,
+		SkipGenerated: cfg.SkipGenerated, LowMemory: cfg.LowMemory, Generate: cfg.Generate,
+		ExcludeFiles: cfg.ExcludeFiles, Platforms: cfg.Platforms,
+	}
+	if isGoRootDir(dir) {
+		// A Go upgrade in place keeps the directories of GOROOT.
+		opts.GoVersion = goRootVersion()
+	}
+	if ctx := cfg.Context; ctx != nil {
+		opts.Context = true
+		opts.GOOS, opts.GOARCH, opts.CgoEnabled = ctx.GOOS, ctx.GOARCH, ctx.CgoEnabled
+		opts.BuildTags, opts.ToolTags, opts.ReleaseTags = ctx.BuildTags, ctx.ToolTags, ctx.ReleaseTags
+	}
+	data, err := json.Marshal(opts)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// immutableDir reports whether the contents of dir are fixed by its path:
+// it is inside a module version extracted in GOMODCACHE, or in the
+// standard library of a Go release.
+func immutableDir(dir string) bool {
+	if isGoRootDir(dir) {
+		return !strings.Contains(goRootVersion(), "devel")
+	}
+	rel, err := filepath.Rel(goModCache(), dir)
+	if err != nil || strings.HasPrefix(rel, "..") || strings.HasPrefix(rel, "cache"+string(filepath.Separator)) {
+		return false
+	}
+	return strings.Contains(rel, "@")
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// isPattern reports whether arg is a "..." wildcard pattern such as
+// "./..." or "github.com/foo/bar/...".
+func isPattern(arg string) bool {
+	return arg == "..." || strings.HasSuffix(arg, "/...")
+}
+
+// patternRoot returns the part of a pattern before the trailing "/...".
+func patternRoot(pattern string) string {
+	if pattern == "..." {
+		return "."
+	}
+	return strings.TrimSuffix(pattern, "/...")
+}
+
+// allPackagesPattern returns the pattern matching every package below arg
+// for --all-packages: arg itself if it is a pattern already.
+func allPackagesPattern(arg string) string {
+	if isPattern(arg) {
+		return arg
+	}
+	return strings.TrimSuffix(arg, "/") + "/..."
+}
+
+// walkPackageDirs returns every directory below root (root included) that
+// could hold a package, following the go command's rules: testdata and
+// vendor directories, directories starting with "." or "_", and nested
+// modules are skipped, and so are the directories more than maxDepth
+// levels below root unless maxDepth is negative. The returned paths are
+// relative to root and use forward slashes; root itself is ".".
+func walkPackageDirs(root string, maxDepth int) ([]string, error) {
+	dirs, err := walkPackageDirsFS(os.DirFS(root), maxDepth)
+	if pe, ok := err.(*fs.PathError); ok {
+		pe.Path = filepath.Join(root, filepath.FromSlash(pe.Path))
+	}
+	return dirs, err
+}
+
+// walkPackageDirsFS is like walkPackageDirs for the tree rooted at "." in
+// fsys, such as a git revision read by gitTree.
+func walkPackageDirsFS(fsys fs.FS, maxDepth int) ([]string, error) {
+	dirs := []string{}
+	err := fs.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if p != "." {
+			name := d.Name()
+			if name == "testdata" || name == "vendor" || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") {
+				return fs.SkipDir
+			}
+			if _, err := fs.Stat(fsys, path.Join(p, "go.mod")); err == nil {
+				return fs.SkipDir
+			}
+		}
+		if maxDepth >= 0 && depth(p) > maxDepth {
+			return fs.SkipDir
+		}
+		dirs = append(dirs, p)
+		return nil
+	})
+	return dirs, err
+}
+
+// dropInternal removes the directories with an "internal" element from
+// the relative paths returned by walkPackageDirs, unless
+// --include-internal is set. Their packages can only be imported from
+// within the tree they are in, so they are not part of its API.
+func dropInternal(rels []string) []string {
+	if *includeInternal {
+		return rels
+	}
+	res := []string{}
+	for _, rel := range rels {
+		if !isInternalPath(rel) {
+			res = append(res, rel)
+		}
+	}
+	return res
+}
+
+// dropExcluded removes the directories whose import path below the
+// pattern root matches an --exclude-pkg pattern from the relative paths
+// returned by walkPackageDirs.
+func dropExcluded(root string, rels []string) []string {
+	if len(excludePkgRes) == 0 {
+		return rels
+	}
+	res := []string{}
+	for _, rel := range rels {
+		if !excludedPackage(joinImportPath(root, rel)) {
+			res = append(res, rel)
+		}
+	}
+	return res
+}
+
+// excludedPackage reports whether an import path matches an --exclude-pkg
+// pattern.
+func excludedPackage(importPath string) bool {
+	for _, re := range excludePkgRes {
+		if re.MatchString(importPath) {
+			return true
+		}
+	}
+	return false
+}
+
+// isInternalPath reports whether a slash-separated path has an "internal"
+// element.
+func isInternalPath(path string) bool {
+	for _, elem := range strings.Split(path, "/") {
+		if elem == "internal" {
+			return true
+		}
+	}
+	return false
+}
+
+// joinImportPath appends a slash-separated relative directory to the import
+// path (or local path) of a pattern root.
+func joinImportPath(root, rel string) string {
+	if rel == "." {
+		return root
+	}
+	if root == "." {
+		return "./" + rel
+	}
+	return root + "/" + rel
+}
@@ -0,0 +1,30 @@
+package export
+
+import (
+	"go/ast"
+	"regexp"
+	"strconv"
+)
+
+// cgoRef matches a reference to the C pseudo-package in a signature.
+var cgoRef = regexp.MustCompile(`(^|[^\w.])C\.\w`)
+
+// importsC reports whether f imports the C pseudo-package of cgo.
+func importsC(f *ast.File) bool {
+	for _, imp := range f.Imports {
+		if path, _ := strconv.Unquote(imp.Path.Value); path == "C" {
+			return true
+		}
+	}
+	return false
+}
+
+// markCgo sets Cgo on the symbols of a cgo file whose signature refers to
+// the C pseudo-package.
+func markCgo(syms []*Symbol) {
+	for _, sym := range syms {
+		if cgoRef.MatchString(sym.Signature) {
+			sym.Cgo = true
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package export
+
+// Combine merges the files of p into a single File with an empty name,
+// presenting the package as one unit the way go doc does: types, each
+// followed by the constants grouped with it by GroupEnums and by its
+// methods, then functions, variables and constants. Within each of these
+// groups symbols keep their order, files taken by name.
+func (p *Package) Combine() {
+	var types, funcs, vars, consts []*Symbol
+	members := map[string][]*Symbol{} // type name -> enum constants and methods
+	typeNames := map[string]bool{}
+	for _, sym := range p.Symbols() {
+		if sym.Kind == KindType {
+			typeNames[sym.Name] = true
+		}
+	}
+	for _, sym := range p.Symbols() {
+		switch {
+		case sym.Kind == KindType:
+			types = append(types, sym)
+		case sym.Enum != "" && typeNames[sym.Enum]:
+			members[sym.Enum] = append(members[sym.Enum], sym)
+		case sym.Kind == KindMethod && typeNames[receiverName(sym)]:
+			members[receiverName(sym)] = append(members[receiverName(sym)], sym)
+		case sym.Kind == KindMethod:
+			// The receiver type was filtered out.
+			types = append(types, sym)
+		case sym.Kind == KindFunc:
+			funcs = append(funcs, sym)
+		case sym.Kind == KindVar:
+			vars = append(vars, sym)
+		default:
+			consts = append(consts, sym)
+		}
+	}
+	syms := []*Symbol{}
+	for _, sym := range types {
+		syms = append(syms, sym)
+		if sym.Kind == KindType {
+			syms = append(syms, enumFirst(members[sym.Name])...)
+		}
+	}
+	syms = append(syms, funcs...)
+	syms = append(syms, vars...)
+	syms = append(syms, consts...)
+	p.Files = []*File{}
+	if len(syms) > 0 {
+		p.Files = append(p.Files, &File{Symbols: syms})
+	}
+}
+
+// enumFirst moves the enum constants among the members of a type before
+// its methods.
+func enumFirst(members []*Symbol) []*Symbol {
+	res := []*Symbol{}
+	for _, sym := range members {
+		if sym.Kind == KindConst {
+			res = append(res, sym)
+		}
+	}
+	for _, sym := range members {
+		if sym.Kind != KindConst {
+			res = append(res, sym)
+		}
+	}
+	return res
+}
@@ -0,0 +1,160 @@
+package export
+
+import (
+	"sort"
+	"strings"
+)
+
+// ChangeKind classifies a difference between two versions of a package.
+type ChangeKind string
+
+const (
+	Added   ChangeKind = "added"
+	Removed ChangeKind = "removed"
+	Changed ChangeKind = "changed"
+)
+
+// Change is a single difference between two versions of a package's API.
+// Old is nil for added symbols and New is nil for removed ones. Breaking
+// reports whether the change can stop existing users from compiling.
+type Change struct {
+	Kind     ChangeKind `json:"kind"`
+	Symbol   string     `json:"symbol"`
+	Breaking bool       `json:"breaking"`
+	Old      *Symbol    `json:"old,omitempty"`
+	New      *Symbol    `json:"new,omitempty"`
+}
+
+// Impact is the overall effect of a set of changes on users of a package.
+type Impact string
+
+const (
+	ImpactNone     Impact = "none"
+	ImpactAdditive Impact = "additive"
+	ImpactBreaking Impact = "breaking"
+)
+
+// Bump returns the semver component that should be incremented for a
+// release with this impact: "major", "minor" or "patch".
+func (i Impact) Bump() string {
+	switch i {
+	case ImpactBreaking:
+		return "major"
+	case ImpactAdditive:
+		return "minor"
+	default:
+		return "patch"
+	}
+}
+
+// ImpactOf classifies a set of changes: breaking if any change is
+// breaking, additive if there are only compatible changes, none otherwise.
+func ImpactOf(changes []*Change) Impact {
+	impact := ImpactNone
+	for _, c := range changes {
+		if c.Breaking {
+			return ImpactBreaking
+		}
+		impact = ImpactAdditive
+	}
+	return impact
+}
+
+// Key identifies a symbol within its package: its name, qualified by the
+// receiver's base type name for methods (e.g. "Client.Do").
+func (s *Symbol) Key() string {
+	if s.Kind != KindMethod {
+		return s.Name
+	}
+	return receiverName(s) + "." + s.Name
+}
+
+// receiverName returns the base type name of a method's receiver.
+func receiverName(s *Symbol) string {
+	recv := strings.TrimPrefix(s.Receiver, "*")
+	if i := strings.Index(recv, "["); i >= 0 {
+		recv = recv[:i]
+	}
+	return recv
+}
+
+// Symbols returns all symbols of the package across its files, in file
+// order.
+func (p *Package) Symbols() []*Symbol {
+	res := []*Symbol{}
+	for _, f := range p.Files {
+		res = append(res, f.Symbols...)
+	}
+	return res
+}
+
+// Diff reports the symbols that were added, removed or whose signature
+// changed between old and new, sorted by symbol key. Signatures are
+// compared textually, so both packages should be parsed with the same
+// Config, preferably with OmitParamNames set.
+func Diff(old, new *Package) []*Change {
+	oldSyms := map[string]*Symbol{}
+	for _, sym := range old.Symbols() {
+		oldSyms[sym.Key()] = sym
+	}
+	newSyms := map[string]*Symbol{}
+	for _, sym := range new.Symbols() {
+		newSyms[sym.Key()] = sym
+	}
+
+	changes := []*Change{}
+	for key, o := range oldSyms {
+		n, ok := newSyms[key]
+		if !ok {
+			changes = append(changes, &Change{Kind: Removed, Symbol: key, Breaking: true, Old: o})
+		} else if normalizeSignature(o.Signature) != normalizeSignature(n.Signature) {
+			changes = append(changes, &Change{Kind: Changed, Symbol: key, Breaking: !compatibleChange(o, n), Old: o, New: n})
+		}
+	}
+	for key, n := range newSyms {
+		if _, ok := oldSyms[key]; !ok {
+			changes = append(changes, &Change{Kind: Added, Symbol: key, New: n})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Symbol < changes[j].Symbol
+	})
+	return changes
+}
+
+// normalizeSignature rewrites spellings that denote identical types, so
+// that e.g. replacing interface{} by any is not reported as a change.
+func normalizeSignature(sig string) string {
+	return strings.ReplaceAll(sig, "interface{}", "any")
+}
+
+// compatibleChange reports whether replacing old by new keeps existing
+// code compiling. Only two cases are recognized: a struct that gains
+// fields, and an interface that gains methods but could not be implemented
+// outside its package anyway because it has unexported methods.
+func compatibleChange(old, new *Symbol) bool {
+	if old.Kind != KindType || new.Kind != KindType {
+		return false
+	}
+	oldHead, oldBody, ok := strings.Cut(old.Signature, "\n")
+	if !ok {
+		return false
+	}
+	newHead, newBody, ok := strings.Cut(new.Signature, "\n")
+	if !ok || oldHead != newHead {
+		return false
+	}
+	newLines := map[string]bool{}
+	for _, line := range strings.Split(newBody, "\n") {
+		newLines[line] = true
+	}
+	for _, line := range strings.Split(oldBody, "\n") {
+		if !newLines[line] {
+			return false
+		}
+	}
+	if strings.HasSuffix(oldHead, " struct {") {
+		return true
+	}
+	return strings.HasSuffix(oldHead, " interface {") && strings.Contains(oldBody, "// contains filtered or unexported methods")
+}
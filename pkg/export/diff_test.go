@@ -0,0 +1,165 @@
+package export
+
+import (
+	"testing"
+)
+
+func TestCompatibleChange(t *testing.T) {
+	tests := []struct {
+		name     string
+		old, new *Symbol
+		want     bool
+	}{
+		{
+			name: "struct gains a field",
+			old:  &Symbol{Kind: KindType, Signature: "type S struct {\n\tA int\n}"},
+			new:  &Symbol{Kind: KindType, Signature: "type S struct {\n\tA int\n\tB string\n}"},
+			want: true,
+		},
+		{
+			name: "struct loses a field",
+			old:  &Symbol{Kind: KindType, Signature: "type S struct {\n\tA int\n\tB string\n}"},
+			new:  &Symbol{Kind: KindType, Signature: "type S struct {\n\tA int\n}"},
+		},
+		{
+			name: "struct field changes type",
+			old:  &Symbol{Kind: KindType, Signature: "type S struct {\n\tA int\n}"},
+			new:  &Symbol{Kind: KindType, Signature: "type S struct {\n\tA int64\n}"},
+		},
+		{
+			name: "struct becomes generic",
+			old:  &Symbol{Kind: KindType, Signature: "type S struct {\n\tA int\n}"},
+			new:  &Symbol{Kind: KindType, Signature: "type S[T any] struct {\n\tA int\n}"},
+		},
+		{
+			name: "sealed interface gains a method",
+			old:  &Symbol{Kind: KindType, Signature: "type I interface {\n\tM()\n\t// contains filtered or unexported methods\n}"},
+			new:  &Symbol{Kind: KindType, Signature: "type I interface {\n\tM()\n\tN()\n\t// contains filtered or unexported methods\n}"},
+			want: true,
+		},
+		{
+			name: "open interface gains a method",
+			old:  &Symbol{Kind: KindType, Signature: "type I interface {\n\tM()\n}"},
+			new:  &Symbol{Kind: KindType, Signature: "type I interface {\n\tM()\n\tN()\n}"},
+		},
+		{
+			name: "collapsed struct",
+			old:  &Symbol{Kind: KindType, Signature: "type S struct{}"},
+			new:  &Symbol{Kind: KindType, Signature: "type S struct {\n\tA int\n}"},
+		},
+		{
+			name: "func gains a parameter",
+			old:  &Symbol{Kind: KindFunc, Signature: "func F()"},
+			new:  &Symbol{Kind: KindFunc, Signature: "func F(int)"},
+		},
+		{
+			name: "type becomes a func",
+			old:  &Symbol{Kind: KindType, Signature: "type S struct {\n\tA int\n}"},
+			new:  &Symbol{Kind: KindFunc, Signature: "func S()"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := compatibleChange(tt.old, tt.new); got != tt.want {
+				t.Errorf("compatibleChange() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiff(t *testing.T) {
+	pkg := func(syms ...*Symbol) *Package {
+		return &Package{Files: []*File{{Symbols: syms}}}
+	}
+	f := &Symbol{Kind: KindFunc, Name: "F", Signature: "func F(interface{})"}
+	fAny := &Symbol{Kind: KindFunc, Name: "F", Signature: "func F(any)"}
+	fInt := &Symbol{Kind: KindFunc, Name: "F", Signature: "func F(int)"}
+	s := &Symbol{Kind: KindType, Name: "S", Signature: "type S struct {\n\tA int\n}"}
+	s2 := &Symbol{Kind: KindType, Name: "S", Signature: "type S struct {\n\tA int\n\tB int\n}"}
+	m := &Symbol{Kind: KindMethod, Name: "M", Receiver: "*S", Signature: "func (*S) M()"}
+	mT := &Symbol{Kind: KindMethod, Name: "M", Receiver: "T", Signature: "func (T) M()"}
+
+	type change struct {
+		kind     ChangeKind
+		symbol   string
+		breaking bool
+	}
+	tests := []struct {
+		name     string
+		old, new *Package
+		want     []change
+		impact   Impact
+		bump     string
+	}{
+		{
+			name:   "unchanged",
+			old:    pkg(f, s),
+			new:    pkg(f, s),
+			impact: ImpactNone,
+			bump:   "patch",
+		},
+		{
+			name:   "interface{} spelled any",
+			old:    pkg(f),
+			new:    pkg(fAny),
+			impact: ImpactNone,
+			bump:   "patch",
+		},
+		{
+			name:   "added method",
+			old:    pkg(s),
+			new:    pkg(s, m),
+			want:   []change{{Added, "S.M", false}},
+			impact: ImpactAdditive,
+			bump:   "minor",
+		},
+		{
+			name:   "struct gains a field",
+			old:    pkg(s),
+			new:    pkg(s2),
+			want:   []change{{Changed, "S", false}},
+			impact: ImpactAdditive,
+			bump:   "minor",
+		},
+		{
+			name:   "changed parameter",
+			old:    pkg(f, s),
+			new:    pkg(fInt, s2),
+			want:   []change{{Changed, "F", true}, {Changed, "S", false}},
+			impact: ImpactBreaking,
+			bump:   "major",
+		},
+		{
+			name:   "method moved to another type",
+			old:    pkg(m),
+			new:    pkg(mT),
+			want:   []change{{Removed, "S.M", true}, {Added, "T.M", false}},
+			impact: ImpactBreaking,
+			bump:   "major",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			changes := Diff(tt.old, tt.new)
+			got := []change{}
+			for _, c := range changes {
+				got = append(got, change{c.Kind, c.Symbol, c.Breaking})
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("Diff() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("Diff()[%d] = %v, want %v", i, got[i], tt.want[i])
+				}
+			}
+			impact := ImpactOf(changes)
+			if impact != tt.impact {
+				t.Errorf("ImpactOf() = %v, want %v", impact, tt.impact)
+			}
+			if bump := impact.Bump(); bump != tt.bump {
+				t.Errorf("Bump() = %v, want %v", bump, tt.bump)
+			}
+		})
+	}
+}
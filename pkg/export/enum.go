@@ -0,0 +1,71 @@
+package export
+
+import "strings"
+
+// basicTypes are the predeclared types an enum type can be defined as.
+var basicTypes = map[string]bool{
+	"bool": true, "string": true,
+	"int": true, "int8": true, "int16": true, "int32": true, "int64": true,
+	"uint": true, "uint8": true, "uint16": true, "uint32": true, "uint64": true, "uintptr": true,
+	"byte": true, "rune": true, "float32": true, "float64": true,
+}
+
+// GroupEnums detects enums, defined types with a basic underlying type
+// and at least two constants of that type, and moves each enum's
+// constants to right after its type declaration, in declaration order,
+// setting their Enum field. Files left without symbols are removed.
+func (p *Package) GroupEnums() {
+	enumTypes := map[string]bool{}
+	for _, sym := range p.Symbols() {
+		if sym.Kind == KindType {
+			underlying := strings.TrimPrefix(sym.Signature, "type "+sym.Name+" ")
+			enumTypes[sym.Name] = basicTypes[underlying]
+		}
+	}
+	members := map[string][]*Symbol{}
+	for _, sym := range p.Symbols() {
+		if typ := constTypeName(sym); enumTypes[typ] {
+			members[typ] = append(members[typ], sym)
+		}
+	}
+	for typ, syms := range members {
+		if len(syms) < 2 {
+			delete(members, typ)
+			continue
+		}
+		for _, sym := range syms {
+			sym.Enum = typ
+		}
+	}
+	if len(members) == 0 {
+		return
+	}
+	for _, f := range p.Files {
+		syms := []*Symbol{}
+		for _, sym := range f.Symbols {
+			if sym.Enum != "" {
+				continue
+			}
+			syms = append(syms, sym)
+			if sym.Kind == KindType {
+				syms = append(syms, members[sym.Name]...)
+			}
+		}
+		f.Symbols = syms
+	}
+	p.Filter(func(*Symbol) bool { return true })
+}
+
+// constTypeName returns the type name in the signature of a typed
+// constant, as in "const A T = 1", or "".
+func constTypeName(sym *Symbol) string {
+	if sym.Kind != KindConst {
+		return ""
+	}
+	decl, _, _ := strings.Cut(sym.Signature, " = ")
+	typ, ok := strings.CutPrefix(decl, "const "+sym.Name+" ")
+	if !ok {
+		return ""
+	}
+	return typ
+}
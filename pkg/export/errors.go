@@ -0,0 +1,22 @@
+package export
+
+import "fmt"
+
+// ParseError reports the files of a package directory that could not be
+// parsed. ParseDir returns it along with the package built from the files
+// that did parse.
+type ParseError struct {
+	Dir  string
+	Errs []error // one per file, as returned by go/parser
+}
+
+func (e *ParseError) Error() string {
+	if len(e.Errs) == 1 {
+		return e.Errs[0].Error()
+	}
+	return fmt.Sprintf("%v (and %d more files with errors)", e.Errs[0], len(e.Errs)-1)
+}
+
+func (e *ParseError) Unwrap() []error {
+	return e.Errs
+}
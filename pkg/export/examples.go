@@ -0,0 +1,99 @@
+package export
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Example is a testable example function found in a _test.go file of the
+// package or of its external test package.
+type Example struct {
+	// Name is the name of the function, such as "ExampleClient_Do_retry".
+	Name string `json:"name"`
+
+	// Symbol is the key of the symbol the example demonstrates, such as
+	// "Client.Do", or empty for an example of the whole package.
+	Symbol string `json:"symbol,omitempty"`
+
+	// Suffix distinguishes examples of the same symbol, as "retry" does
+	// above.
+	Suffix string `json:"suffix,omitempty"`
+
+	// File is the name of the test file declaring the example.
+	File string `json:"file"`
+}
+
+// examples returns the example functions of the _test.go files of dir that
+// belong to package pkgName or pkgName_test, sorted by name. Files that do
+// not parse are skipped: examples are an addition to the package's API,
+// and the test files are not what was asked to be listed.
+func (c *Config) examples(fsys fs.FS, dir, pkgName string) []*Example {
+	list, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil
+	}
+	res := []*Example{}
+	fset := token.NewFileSet()
+	for _, d := range list {
+		if d.IsDir() || !strings.HasSuffix(d.Name(), "_test.go") {
+			continue
+		}
+		if c.Context != nil && !matchFile(*c.Context, fsys, dir, d.Name()) {
+			continue
+		}
+		data, err := fs.ReadFile(fsys, path.Join(dir, d.Name()))
+		if err != nil {
+			continue
+		}
+		src, err := parser.ParseFile(fset, filepath.Join(dir, d.Name()), data, parser.SkipObjectResolution)
+		if err != nil || src.Name.Name != pkgName && src.Name.Name != pkgName+"_test" {
+			continue
+		}
+		for _, decl := range src.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || fn.Recv != nil || !isExampleFunc(fn) {
+				continue
+			}
+			symbol, suffix := splitExampleName(strings.TrimPrefix(fn.Name.Name, "Example"))
+			res = append(res, &Example{Name: fn.Name.Name, Symbol: symbol, Suffix: suffix, File: d.Name()})
+		}
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Name < res[j].Name })
+	return res
+}
+
+// isExampleFunc reports whether fn is named and declared like a testable
+// example: "Example", optionally followed by "_" or an upper case letter,
+// and taking and returning nothing.
+func isExampleFunc(fn *ast.FuncDecl) bool {
+	rest, ok := strings.CutPrefix(fn.Name.Name, "Example")
+	if !ok || fn.Type.TypeParams != nil || fn.Type.Params.NumFields() > 0 || fn.Type.Results.NumFields() > 0 {
+		return false
+	}
+	if rest == "" || rest[0] == '_' {
+		return true
+	}
+	r, _ := utf8.DecodeRuneInString(rest)
+	return unicode.IsUpper(r)
+}
+
+// splitExampleName splits the name of an example without its "Example"
+// prefix into the key of the symbol it demonstrates and its suffix, which
+// starts with a lower case letter, as the go tool does: "T_M_suffix"
+// demonstrates "T.M", "_suffix" the package.
+func splitExampleName(name string) (symbol, suffix string) {
+	if i := strings.LastIndex(name, "_"); i >= 0 {
+		if r, _ := utf8.DecodeRuneInString(name[i+1:]); unicode.IsLower(r) {
+			name, suffix = name[:i], name[i+1:]
+		}
+	}
+	return strings.Replace(name, "_", ".", 1), suffix
+}
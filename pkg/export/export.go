@@ -0,0 +1,873 @@
+// Package export extracts the exported API of a Go package directory.
+package export
+
+import (
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// Kind is the kind of an exported declaration.
+type Kind string
+
+const (
+	KindFunc   Kind = "func"
+	KindMethod Kind = "method"
+	KindType   Kind = "type"
+	KindVar    Kind = "var"
+	KindConst  Kind = "const"
+)
+
+// Package is the exported API of a single package directory.
+type Package struct {
+	Path    string  `json:"path"`
+	Name    string  `json:"name"`
+	Module  string  `json:"module,omitempty"`
+	Version string  `json:"version,omitempty"`
+	Dir     string  `json:"dir"`
+	Doc     string  `json:"doc,omitempty"` // the package comment, with Config.Docs
+	Files   []*File `json:"files"`
+
+	// Examples are the package's example functions, with
+	// Config.Examples.
+	Examples []*Example `json:"examples,omitempty"`
+
+	// Generate are the package's //go:generate directives, with
+	// Config.Generate.
+	Generate []*Directive `json:"generate,omitempty"`
+
+	// typesPkg is the type-checked package when parsed with Config.Types.
+	typesPkg *types.Package
+
+	// state is what Config.Reparse reuses: where the package was parsed
+	// from and, with Config.Incremental, its syntax trees.
+	state *parseState
+}
+
+// parseState records how a package was parsed, for Config.Reparse. fset
+// and files are only set with Config.Incremental.
+type parseState struct {
+	fsys       fs.FS
+	dir        string
+	displayDir string
+	fset       *token.FileSet
+	files      map[string]*parsedFile
+}
+
+// parsedFile is a source file as parsed, with the size and modification
+// time it had then.
+type parsedFile struct {
+	size    int64
+	modTime time.Time
+	src     *ast.File
+	err     error
+}
+
+// File holds the exported declarations of one source file, in source order.
+type File struct {
+	Name string `json:"name"`
+
+	// Package is the package clause of the file when it differs from
+	// Package.Name: that of the external test package.
+	Package string `json:"package,omitempty"`
+
+	// Generated is set for files marked as generated by a
+	// "// Code generated ... DO NOT EDIT." comment.
+	Generated bool `json:"generated,omitempty"`
+
+	Symbols []*Symbol `json:"symbols"`
+}
+
+// Symbol is a single exported declaration.
+type Symbol struct {
+	Kind      Kind   `json:"kind"`
+	Name      string `json:"name"`
+	Receiver  string `json:"receiver,omitempty"`
+	Signature string `json:"signature"`
+	Doc       string `json:"doc,omitempty"`
+
+	// Deprecated holds the text of the "Deprecated:" paragraph of the
+	// symbol's doc comment; it is empty for symbols that are not
+	// deprecated.
+	Deprecated string `json:"deprecated,omitempty"`
+
+	// Stability is the API guarantee that a marker in the symbol's doc
+	// comment gives it: "stable", "experimental" or "internal" for a
+	// paragraph starting with "Stable:", "Experimental:" or "Internal:",
+	// or the lowercased level of an "//api:level" directive. It is empty
+	// for unmarked symbols.
+	Stability string `json:"stability,omitempty"`
+
+	// Platforms lists the platforms the symbol is declared for, when
+	// Config.Platforms is set and its file is not built on all of them.
+	Platforms []string `json:"platforms,omitempty"`
+
+	// Position is the declaration's "file.go:line" location, with
+	// Config.Positions set.
+	Position string `json:"position,omitempty"`
+
+	// URL links to the symbol's documentation. It is left for the
+	// caller to fill in, like Package.Path.
+	URL string `json:"url,omitempty"`
+
+	// Promoted is set on the methods a struct type gains from its
+	// embedded fields, listed with Config.Types, to the receiver type
+	// that declares them.
+	Promoted string `json:"promoted,omitempty"`
+
+	// NoBody is set, with Config.Types, on the functions and methods
+	// declared without a body: to "assembly" when the package has
+	// assembly files, and to "external" otherwise, as for functions
+	// provided with go:linkname.
+	NoBody string `json:"nobody,omitempty"`
+
+	// Internal marks the symbols of internal packages, which are
+	// exported but can only be imported from within the tree rooted at
+	// the parent of the "internal" directory. It is left for the caller
+	// to fill in, like Package.Path.
+	Internal bool `json:"internal,omitempty"`
+
+	// Cgo is set on the symbols of cgo files whose signature refers to
+	// the C pseudo-package, as in "func F(n C.int)". Such types depend on
+	// the C preprocessing of the file and are not usable outside the
+	// package.
+	Cgo bool `json:"cgo,omitempty"`
+
+	// Enum is set by Package.GroupEnums on the constants of an enum type
+	// to the name of the type.
+	Enum string `json:"enum,omitempty"`
+
+	// Pos is the position of the declared name and End that of the end
+	// of its declaration. Both are nil for files passed to FileExports
+	// directly, which carry no file set.
+	Pos *Position `json:"-"`
+	End *Position `json:"-"`
+}
+
+// Position is a location in a source file. Line and Column are 1-based;
+// Column and Offset count bytes.
+type Position struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+	Offset int `json:"offset"`
+}
+
+// Config controls how exported declarations are extracted and rendered.
+// The zero value is ready to use.
+type Config struct {
+	// Compact collapses struct and interface bodies to "struct{}" and
+	// "interface{}" instead of listing their exported fields and methods.
+	Compact bool
+
+	// OmitParamNames drops the names of receivers, parameters and results
+	// from signatures, so that renaming them does not change the output.
+	OmitParamNames bool
+
+	// Docs attaches each symbol's doc comment to Symbol.Doc.
+	Docs bool
+
+	// ExpandLiterals renders the elements of composite literals in the
+	// values of vars, as in "var Defaults = Config{Timeout: 5}", instead
+	// of abbreviating them to "Config{}".
+	ExpandLiterals bool
+
+	// Positions records each symbol's location in Symbol.Position.
+	Positions bool
+
+	// Types type-checks the package with go/types and renders functions,
+	// methods and the types of vars and consts from the resolved types
+	// rather than from the syntax. Imports are type-checked from source;
+	// declarations whose types cannot be resolved keep their syntactic
+	// rendering.
+	Types bool
+
+	// Importer imports the dependencies of packages type-checked with
+	// Types. Sharing one importer between parses makes the types of
+	// common dependencies identical across packages. Nil means a new
+	// source importer per package.
+	Importer types.Importer
+
+	// Context, if set, restricts parsing to the files that match its
+	// GOOS, GOARCH and build tags. With a nil Context every file is
+	// parsed regardless of build constraints.
+	Context *build.Context
+
+	// Platforms lists "goos/goarch" pairs. When set, every file is parsed
+	// and each symbol records which of these platforms its file is built
+	// for, producing a union view of all platforms.
+	Platforms []string
+
+	// Examples lists the testable example functions of the package's
+	// test files in Package.Examples.
+	Examples bool
+
+	// Generate lists the //go:generate directives of the package's
+	// files, test files included, in Package.Generate.
+	Generate bool
+
+	// ExternalTests also lists the exported helpers declared in the
+	// package's external test package, pkg_test, other than test,
+	// benchmark, fuzz and example functions. Their files have
+	// File.Package set. They are rendered from the syntax even with
+	// Types.
+	ExternalTests bool
+
+	// SkipCgo leaves out the files that import "C". Otherwise they are
+	// parsed whether or not cgo is enabled in the environment or in
+	// Context, so the output does not depend on it.
+	SkipCgo bool
+
+	// SkipGenerated leaves out the files marked as generated by a
+	// "// Code generated ... DO NOT EDIT." comment, as go/ast.IsGenerated
+	// recognizes them.
+	SkipGenerated bool
+
+	// ExcludeFiles leaves out the files whose base name matches one of
+	// these path.Match patterns, such as "zz_generated*".
+	ExcludeFiles []string
+
+	// Jobs bounds the number of files parsed concurrently. Zero means
+	// runtime.GOMAXPROCS(0).
+	Jobs int
+
+	// Incremental keeps the syntax trees of the parsed files with the
+	// package, so that Reparse can read and parse again only the files
+	// that changed. They take memory for as long as the package is kept.
+	Incremental bool
+
+	// LowMemory parses the files of a package one at a time, extracting
+	// the exports of each before parsing the next, so that a single
+	// syntax tree is held in memory at once. Constants are evaluated
+	// with the declarations of their own file only: those defined in
+	// terms of constants of other files have no value. LowMemory is
+	// ignored with Types, which needs every file at once, and makes
+	// Incremental and Jobs ineffective.
+	LowMemory bool
+
+	// typesPkg is the type-checked package while ParseDir extracts
+	// symbols with Types set.
+	typesPkg *types.Package
+
+	// constPkg is the package type-checked for the values of its
+	// constants: typesPkg with Types set, and otherwise a check without
+	// imports, which evaluates the constants that do not depend on other
+	// packages.
+	constPkg *types.Package
+
+	// hasAsm is set when the directory ParseDir extracts symbols from
+	// has assembly files.
+	hasAsm bool
+
+	// fset holds the positions of the files ParseDir extracts symbols
+	// from.
+	fset *token.FileSet
+}
+
+// Filter removes the symbols for which keep returns false, then any file
+// left without symbols and the examples of the removed symbols.
+func (p *Package) Filter(keep func(*Symbol) bool) {
+	removed := map[string]bool{}
+	files := p.Files[:0]
+	for _, f := range p.Files {
+		syms := f.Symbols[:0]
+		for _, sym := range f.Symbols {
+			if keep(sym) {
+				syms = append(syms, sym)
+			} else {
+				removed[sym.Key()] = true
+			}
+		}
+		f.Symbols = syms
+		if len(f.Symbols) > 0 {
+			files = append(files, f)
+		}
+	}
+	p.Files = files
+	if len(removed) > 0 && len(p.Examples) > 0 {
+		examples := p.Examples[:0]
+		for _, ex := range p.Examples {
+			if !removed[ex.Symbol] {
+				examples = append(examples, ex)
+			}
+		}
+		p.Examples = examples
+	}
+}
+
+// ParseDir parses the package in dir using the default Config.
+func ParseDir(dir string) (*Package, error) {
+	return new(Config).ParseDir(dir)
+}
+
+// FileExports returns the exported declarations of f using the default
+// Config.
+func FileExports(name string, f *ast.File) *File {
+	return new(Config).FileExports(name, f)
+}
+
+// ParseDir parses the non-test Go files in dir and returns their exported
+// declarations. Files are sorted by name and files of package main are
+// skipped. Files that fail to parse are left out as well and reported by a
+// *ParseError, which is returned together with the package built from the
+// remaining files. Path and Version are left for the caller to fill in,
+// since they depend on how dir was resolved.
+func (c *Config) ParseDir(dir string) (*Package, error) {
+	return c.parseFS(os.DirFS(dir), ".", dir)
+}
+
+// ParseFS is like ParseDir but reads the package from directory dir of
+// fsys, such as a module zip opened with archive/zip.
+func (c *Config) ParseFS(fsys fs.FS, dir string) (*Package, error) {
+	return c.parseFS(fsys, dir, dir)
+}
+
+// ParseFiles is like ParseDir but parses only the named Go files, which
+// must all be in the same directory. Build constraints are ignored, since
+// the files were chosen explicitly.
+func (c *Config) ParseFiles(filenames ...string) (*Package, error) {
+	if len(filenames) == 0 {
+		return nil, fmt.Errorf("no files to parse")
+	}
+	dir := filepath.Dir(filenames[0])
+	names := map[string]bool{}
+	for _, name := range filenames {
+		if d := filepath.Dir(name); d != dir {
+			return nil, fmt.Errorf("named files must all be in one directory: have %s and %s", dir, d)
+		}
+		if _, err := os.Stat(name); err != nil {
+			return nil, err
+		}
+		names[filepath.Base(name)] = true
+	}
+	cc := *c
+	cc.Context = nil
+	return cc.parseFS(namedFS{os.DirFS(dir), names}, ".", dir)
+}
+
+// namedFS is a directory whose listing is limited to names.
+type namedFS struct {
+	fs.FS
+	names map[string]bool
+}
+
+func (f namedFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	list, err := fs.ReadDir(f.FS, name)
+	if name != "." {
+		return list, err
+	}
+	kept := list[:0]
+	for _, d := range list {
+		if f.names[d.Name()] {
+			kept = append(kept, d)
+		}
+	}
+	return kept, err
+}
+
+// Reparse parses the directory of p again, as the call that returned p
+// did, but only reads and parses the files that were added or modified
+// since, as told by their size and modification time, reusing the syntax
+// trees of the others. The symbols of every file are extracted again, as
+// a change to one file can affect the rendering of another, such as the
+// value of a constant. p itself is left unchanged. If p was not parsed
+// with Incremental, every file is read again; a package from ParseFiles
+// still gets only its named files. A package that was not parsed by a
+// Config, such as one decoded from JSON, gets its whole directory parsed.
+func (c *Config) Reparse(p *Package) (*Package, error) {
+	if p.state == nil {
+		return c.ParseDir(p.Dir)
+	}
+	cc := *c
+	if _, ok := p.state.fsys.(namedFS); ok {
+		cc.Context = nil // as with ParseFiles
+	}
+	return cc.parse(p.state.fsys, p.state.dir, p.state.displayDir, p.state)
+}
+
+// parseFS parses directory dir of fsys. displayDir is reported as
+// Package.Dir and used to name the files in positions.
+func (c *Config) parseFS(fsys fs.FS, dir, displayDir string) (*Package, error) {
+	return c.parse(fsys, dir, displayDir, nil)
+}
+
+// parse is parseFS reusing the files of prev, if not nil, that did not
+// change since.
+func (c *Config) parse(fsys fs.FS, dir, displayDir string, prev *parseState) (*Package, error) {
+	if c.LowMemory && !c.Types {
+		var files []*File
+		pkg, err := c.stream(fsys, dir, displayDir, func(_ *Package, f *File) { files = append(files, f) })
+		if pkg != nil {
+			sort.SliceStable(files, func(i, j int) bool { return files[i].Name < files[j].Name })
+			pkg.Files = append(pkg.Files, files...)
+			pkg.state = &parseState{fsys: fsys, dir: dir, displayDir: displayDir}
+		}
+		return pkg, err
+	}
+	names, entries, hasAsm, err := c.goFiles(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	pkg := &Package{
+		Dir:   displayDir,
+		Files: []*File{},
+	}
+	fset := token.NewFileSet()
+	if prev != nil && prev.fset != nil {
+		fset = prev.fset
+	}
+	parsed := make([]*ast.File, len(names))
+	readErrs := make([]error, len(names))
+	parseErrs := make([]error, len(names))
+	states := make([]*parsedFile, len(names))
+	c.parallel(len(names), func(i int) {
+		st := &parsedFile{}
+		if info, err := entries[i].Info(); err == nil {
+			st.size, st.modTime = info.Size(), info.ModTime()
+		}
+		if prev != nil {
+			if old := prev.files[names[i]]; old != nil && old.size == st.size && old.modTime.Equal(st.modTime) && !st.modTime.IsZero() {
+				states[i], parsed[i], parseErrs[i] = old, old.src, old.err
+				return
+			}
+		}
+		data, err := fs.ReadFile(fsys, path.Join(dir, names[i]))
+		if err != nil {
+			readErrs[i] = err
+			return
+		}
+		parsed[i], parseErrs[i] = parser.ParseFile(fset, filepath.Join(displayDir, names[i]), data, parser.ParseComments)
+		st.src, st.err = parsed[i], parseErrs[i]
+		states[i] = st
+	})
+	pkg.state = &parseState{fsys: fsys, dir: dir, displayDir: displayDir}
+	if c.Incremental {
+		pkg.state.fset, pkg.state.files = fset, map[string]*parsedFile{}
+		for i, name := range names {
+			if states[i] != nil {
+				pkg.state.files[name] = states[i]
+			}
+		}
+	}
+
+	files := []*ast.File{}
+	fileNames := []string{}
+	var xtestFiles []*ast.File
+	var xtestNames []string
+	var parseErr *ParseError
+	for i, src := range parsed {
+		if readErrs[i] != nil {
+			return nil, readErrs[i]
+		}
+		if parseErrs[i] != nil {
+			if parseErr == nil {
+				parseErr = &ParseError{Dir: displayDir}
+			}
+			parseErr.Errs = append(parseErr.Errs, parseErrs[i])
+			continue
+		}
+		if src.Name.Name == "main" { // ignore main package
+			continue
+		}
+		if c.SkipCgo && importsC(src) || c.SkipGenerated && ast.IsGenerated(src) {
+			continue
+		}
+		if strings.HasSuffix(names[i], "_test.go") {
+			// Only external test files are listed, once the package
+			// name is known.
+			if strings.HasSuffix(src.Name.Name, "_test") {
+				xtestFiles = append(xtestFiles, src)
+				xtestNames = append(xtestNames, names[i])
+			}
+			continue
+		}
+		if pkg.Name == "" {
+			pkg.Name = src.Name.Name
+		}
+		if c.Docs && pkg.Doc == "" && src.Doc != nil {
+			pkg.Doc = strings.TrimSpace(src.Doc.Text())
+		}
+		files = append(files, src)
+		fileNames = append(fileNames, names[i])
+	}
+	names = fileNames
+
+	cc := *c
+	cc.fset = fset
+	cc.hasAsm = hasAsm
+	if c.Types && len(files) > 0 {
+		cc.typesPkg = c.typeCheck(fset, files)
+		pkg.typesPkg = cc.typesPkg
+		cc.constPkg = cc.typesPkg
+	} else if len(files) > 0 {
+		cc.constPkg = typeCheckLocal(fset, files)
+	}
+	for i, src := range files {
+		f := cc.FileExports(names[i], src)
+		if len(f.Symbols) == 0 {
+			continue
+		}
+		if len(c.Platforms) > 0 {
+			platforms := c.condensePlatforms(c.filePlatforms(fsys, dir, names[i]))
+			for _, sym := range f.Symbols {
+				sym.Platforms = platforms
+			}
+		}
+		pkg.Files = append(pkg.Files, f)
+	}
+	if len(xtestFiles) > 0 && pkg.Name != "" {
+		pkg.Files = append(pkg.Files, c.xtestExports(fset, xtestNames, xtestFiles, pkg.Name)...)
+		sort.Slice(pkg.Files, func(i, j int) bool { return pkg.Files[i].Name < pkg.Files[j].Name })
+	}
+	if c.Examples && pkg.Name != "" {
+		pkg.Examples = c.examples(fsys, dir, pkg.Name)
+	}
+	if c.Generate && pkg.Name != "" {
+		pkg.Generate = c.generateDirectives(fsys, dir, pkg.Name)
+	}
+	if parseErr != nil {
+		return pkg, parseErr
+	}
+	return pkg, nil
+}
+
+// goFiles returns the names and directory entries of the Go files of dir
+// to parse, sorted by name, and whether dir has assembly files.
+func (c *Config) goFiles(fsys fs.FS, dir string) (names []string, entries []fs.DirEntry, hasAsm bool, err error) {
+	list, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, nil, false, err
+	}
+	for _, d := range list { // fs.ReadDir returns entries sorted by filename
+		if !d.IsDir() && strings.HasSuffix(d.Name(), ".s") {
+			hasAsm = true
+		}
+		if d.IsDir() || !strings.HasSuffix(d.Name(), ".go") || strings.HasSuffix(d.Name(), "_test.go") && !c.ExternalTests {
+			continue
+		}
+		if c.excluded(d.Name()) {
+			continue
+		}
+		if c.Context != nil && !matchFile(*c.Context, fsys, dir, d.Name()) {
+			continue
+		}
+		names = append(names, d.Name())
+		entries = append(entries, d)
+	}
+	return names, entries, hasAsm, nil
+}
+
+// excluded reports whether the file name matches one of ExcludeFiles.
+func (c *Config) excluded(name string) bool {
+	for _, pattern := range c.ExcludeFiles {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// parallel calls work(i) for every i in [0, n), running at most Jobs calls
+// at a time, and waits for all of them.
+func (c *Config) parallel(n int, work func(i int)) {
+	jobs := c.Jobs
+	if jobs <= 0 {
+		jobs = runtime.GOMAXPROCS(0)
+	}
+	sem := make(chan struct{}, jobs)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int) {
+			defer func() { <-sem; wg.Done() }()
+			work(i)
+		}(i)
+	}
+	wg.Wait()
+}
+
+// FileExports returns the exported declarations of an already parsed file.
+func (c *Config) FileExports(name string, f *ast.File) *File {
+	res := &File{Name: name, Generated: ast.IsGenerated(f), Symbols: []*Symbol{}}
+	for _, xdecl := range f.Decls {
+		switch decl := xdecl.(type) {
+		case *ast.FuncDecl:
+			if c.exported(decl) {
+				res.Symbols = append(res.Symbols, c.funcSymbol(decl))
+			}
+		case *ast.GenDecl:
+			res.Symbols = append(res.Symbols, c.genDeclSymbols(decl)...)
+		}
+	}
+	if importsC(f) {
+		markCgo(res.Symbols)
+	}
+	return res
+}
+
+func (c *Config) funcSymbol(decl *ast.FuncDecl) *Symbol {
+	sym := &Symbol{
+		Kind:      KindFunc,
+		Name:      decl.Name.Name,
+		Signature: c.formatFuncDecl(decl),
+	}
+	c.setPos(sym, decl.Name.Pos(), decl.End())
+	c.setDoc(sym, decl.Doc)
+	if sig := c.typesFuncSignature(decl); sig != "" {
+		sym.Signature = sig
+	}
+	if decl.Body == nil && c.Types {
+		sym.NoBody = "external"
+		if c.hasAsm {
+			sym.NoBody = "assembly"
+		}
+	}
+	if decl.Recv != nil && len(decl.Recv.List) == 1 {
+		sym.Kind = KindMethod
+		sym.Receiver = c.formatType(decl.Recv.List[0].Type)
+	}
+	return sym
+}
+
+func (c *Config) genDeclSymbols(decl *ast.GenDecl) []*Symbol {
+	res := []*Symbol{}
+	switch decl.Tok {
+	case token.TYPE:
+		for _, spec := range decl.Specs {
+			sp, ok := spec.(*ast.TypeSpec)
+			if ok && isUpper0(sp.Name.Name) {
+				head := sp.Name.Name
+				if sp.TypeParams != nil {
+					head += "[" + oneLine(c.formatFields(sp.TypeParams)) + "]"
+				}
+				format := "type %s %s"
+				if sp.Assign.IsValid() { // alias
+					format = "type %s = %s"
+				}
+				sym := &Symbol{
+					Kind:      KindType,
+					Name:      sp.Name.Name,
+					Signature: fmt.Sprintf(format, head, c.formatType(sp.Type)),
+				}
+				c.setPos(sym, sp.Name.Pos(), sp.End())
+				c.setSpecDoc(sym, decl, sp.Doc)
+				res = append(res, sym)
+				res = append(res, c.promotedMethods(sp.Name.Name)...)
+			}
+		}
+	case token.VAR, token.CONST:
+		key := KindVar
+		if decl.Tok == token.CONST {
+			key = KindConst
+		}
+		// A const spec without type and values repeats the previous
+		// ones, with iota incremented.
+		var lastType ast.Expr
+		for _, spec := range decl.Specs {
+			sp, ok := spec.(*ast.ValueSpec)
+			if !ok {
+				continue
+			}
+			typeExpr := sp.Type
+			if key == KindConst {
+				if sp.Type == nil && len(sp.Values) == 0 {
+					typeExpr = lastType
+				} else {
+					lastType = sp.Type
+				}
+			}
+			for i, name := range sp.Names {
+				if isUpper0(name.Name) {
+					typ := c.formatType(typeExpr)
+					if typ == "" {
+						typ = c.typesObjectType(name.Name)
+					}
+					if typ == "" && key == KindConst {
+						typ = c.constType(name.Name)
+					}
+					if typ != "" {
+						typ += " "
+					}
+					s := fmt.Sprintf("%s %s %s", key, name, typ)
+					value := ""
+					if key == KindConst {
+						value = c.constValue(name.Name)
+					}
+					if value == "" && len(sp.Values) > i {
+						value = c.formatType(sp.Values[i])
+					}
+					if value != "" {
+						s += "= " + value
+					}
+					sym := &Symbol{
+						Kind:      key,
+						Name:      name.Name,
+						Signature: strings.TrimRight(s, " "),
+					}
+					c.setPos(sym, name.Pos(), sp.End())
+					c.setSpecDoc(sym, decl, sp.Doc)
+					res = append(res, sym)
+				}
+			}
+		}
+	}
+	return res
+}
+
+// setPos records where sym is declared, if the file set is known.
+func (c *Config) setPos(sym *Symbol, pos, end token.Pos) {
+	if c.fset == nil {
+		return
+	}
+	sym.Pos = c.position(pos)
+	sym.End = c.position(end)
+	if c.Positions {
+		sym.Position = fmt.Sprintf("%s:%d", filepath.Base(c.fset.Position(pos).Filename), sym.Pos.Line)
+	}
+}
+
+func (c *Config) position(pos token.Pos) *Position {
+	p := c.fset.Position(pos)
+	return &Position{Line: p.Line, Column: p.Column, Offset: p.Offset}
+}
+
+// setSpecDoc fills in the doc-derived fields of a symbol declared by a
+// spec inside decl. A spec without its own comment inherits the comment of
+// an unparenthesized declaration, and a deprecation notice or stability
+// marker on a parenthesized group applies to every spec in it.
+func (c *Config) setSpecDoc(sym *Symbol, decl *ast.GenDecl, doc *ast.CommentGroup) {
+	if doc == nil && !decl.Lparen.IsValid() {
+		doc = decl.Doc
+	}
+	c.setDoc(sym, doc)
+	if sym.Deprecated == "" && decl.Doc != nil {
+		sym.Deprecated = deprecationNotice(decl.Doc.Text())
+	}
+	if sym.Stability == "" && decl.Doc != nil {
+		sym.Stability = stabilityMarker(decl.Doc)
+	}
+}
+
+// setDoc fills in the doc-derived fields of sym: its deprecation notice,
+// its stability, and the doc text itself when Docs is set.
+func (c *Config) setDoc(sym *Symbol, doc *ast.CommentGroup) {
+	if doc == nil {
+		return
+	}
+	text := strings.TrimSpace(doc.Text())
+	if c.Docs {
+		sym.Doc = text
+	}
+	sym.Deprecated = deprecationNotice(text)
+	sym.Stability = stabilityMarker(doc)
+}
+
+// deprecationNotice returns the text of the "Deprecated:" paragraph of a
+// doc comment, with the prefix removed and lines joined, or "" if the
+// symbol is not deprecated.
+func deprecationNotice(doc string) string {
+	for _, para := range strings.Split(doc, "\n\n") {
+		para = strings.TrimSpace(para)
+		if notice, ok := strings.CutPrefix(para, "Deprecated:"); ok {
+			notice = strings.Join(strings.Fields(notice), " ")
+			if notice == "" { // keep the symbol marked even without details
+				notice = "Deprecated."
+			}
+			return notice
+		}
+	}
+	return ""
+}
+
+// stabilityParagraphs are the doc paragraph prefixes that mark the
+// stability of a symbol, with the level they give it.
+var stabilityParagraphs = []struct{ prefix, level string }{
+	{"Stable:", "stable"},
+	{"Experimental:", "experimental"},
+	{"Internal:", "internal"},
+}
+
+// stabilityMarker returns the stability level a doc comment marks its
+// symbol with, or "" if it has no marker. An "//api:level" directive,
+// which doc.Text leaves out, takes precedence over a marker paragraph.
+func stabilityMarker(doc *ast.CommentGroup) string {
+	for _, c := range doc.List {
+		if level, ok := strings.CutPrefix(c.Text, "//api:"); ok {
+			if f := strings.Fields(level); len(f) > 0 {
+				return strings.ToLower(f[0])
+			}
+		}
+	}
+	for _, para := range strings.Split(doc.Text(), "\n\n") {
+		para = strings.TrimSpace(para)
+		for _, m := range stabilityParagraphs {
+			if strings.HasPrefix(para, m.prefix) {
+				return m.level
+			}
+		}
+	}
+	return ""
+}
+
+func isUpper0(s string) bool {
+	if strings.HasPrefix(s, "*") {
+		return unicode.IsUpper([]rune(s)[1])
+	}
+	return unicode.IsUpper([]rune(s)[0])
+}
+
+func (c *Config) exported(decl *ast.FuncDecl) bool {
+	if decl.Recv != nil {
+		if len(decl.Recv.List) != 1 { // rejected by the parser
+			return false
+		}
+		field := decl.Recv.List[0]
+		return isUpper0(c.formatType(field.Type)) && isUpper0(decl.Name.Name)
+	}
+	return isUpper0(decl.Name.Name)
+}
+
+// Clone returns a copy of p whose files, symbols, examples and directives
+// can be changed or filtered without affecting p.
+func (p *Package) Clone() *Package {
+	c := *p
+	c.Files = make([]*File, len(p.Files))
+	for i, f := range p.Files {
+		fc := *f
+		fc.Symbols = make([]*Symbol, len(f.Symbols))
+		for j, sym := range f.Symbols {
+			sc := *sym
+			fc.Symbols[j] = &sc
+		}
+		c.Files[i] = &fc
+	}
+	if p.Examples != nil {
+		c.Examples = make([]*Example, len(p.Examples))
+		for i, ex := range p.Examples {
+			ec := *ex
+			c.Examples[i] = &ec
+		}
+	}
+	if p.Generate != nil {
+		c.Generate = make([]*Directive, len(p.Generate))
+		for i, d := range p.Generate {
+			dc := *d
+			c.Generate[i] = &dc
+		}
+	}
+	return &c
+}
@@ -0,0 +1,347 @@
+package export
+
+import (
+	"fmt"
+	"go/ast"
+	"strconv"
+	"strings"
+)
+
+func (c *Config) formatFuncDecl(decl *ast.FuncDecl) string {
+	s := "func "
+	if decl.Recv != nil {
+		if len(decl.Recv.List) != 1 {
+			return fmt.Sprintf("strange receiver for %s: %#v", decl.Name.Name, decl.Recv)
+		}
+		field := decl.Recv.List[0]
+		if len(field.Names) == 0 || c.OmitParamNames {
+			s += fmt.Sprintf("(%s) ", c.formatType(field.Type))
+		} else if len(field.Names) != 1 {
+			return fmt.Sprintf("strange receiver field for %s: %#v", decl.Name.Name, field)
+		} else {
+			s += fmt.Sprintf("(%s %s) ", field.Names[0], c.formatType(field.Type))
+		}
+	}
+	s += decl.Name.Name
+	if decl.Type.TypeParams != nil {
+		s += fmt.Sprintf("[%s]", oneLine(c.formatFields(decl.Type.TypeParams)))
+	}
+	s += fmt.Sprintf("(%s)", c.formatParams(decl.Type.Params))
+	s += c.formatFuncResults(decl.Type.Results)
+	return s
+}
+
+// oneLine collapses a multi-line rendering, such as a type parameter list
+// with an inline constraint, onto one line the way gofmt writes one-line
+// bodies: "T interface{ ~int | ~string }".
+func oneLine(s string) string {
+	lines := strings.Split(s, "\n")
+	if len(lines) == 1 {
+		return s
+	}
+	var b strings.Builder
+	for i, line := range lines {
+		line = strings.TrimSpace(line)
+		switch {
+		case i == 0:
+		case strings.HasSuffix(lines[i-1], "{") || strings.HasPrefix(line, "}"):
+			b.WriteString(" ")
+		default:
+			b.WriteString("; ")
+		}
+		if strings.HasSuffix(line, " {") {
+			line = strings.TrimSuffix(line, " {") + "{"
+		}
+		b.WriteString(line)
+	}
+	return b.String()
+}
+
+func (c *Config) formatFields(fields *ast.FieldList) string {
+	s := ""
+	for i, field := range fields.List {
+		for j, name := range field.Names {
+			s += name.Name
+			if j != len(field.Names)-1 {
+				s += ","
+			}
+			s += " "
+		}
+		s += c.formatType(field.Type)
+		if i != len(fields.List)-1 {
+			s += ", "
+		}
+	}
+	return s
+}
+
+// formatParams formats a parameter or result list, dropping the parameter
+// names when OmitParamNames is set.
+func (c *Config) formatParams(fields *ast.FieldList) string {
+	if !c.OmitParamNames {
+		return c.formatFields(fields)
+	}
+	types := []string{}
+	for _, field := range fields.List {
+		typ := c.formatType(field.Type)
+		n := len(field.Names)
+		if n == 0 {
+			n = 1
+		}
+		for i := 0; i < n; i++ {
+			types = append(types, typ)
+		}
+	}
+	return strings.Join(types, ", ")
+}
+
+func (c *Config) formatType(typ ast.Expr) string {
+	switch t := typ.(type) {
+	case nil:
+		return ""
+	case *ast.Ident:
+		return t.Name
+	case *ast.SelectorExpr:
+		return fmt.Sprintf("%s.%s", c.formatType(t.X), t.Sel.Name)
+	case *ast.StarExpr:
+		return fmt.Sprintf("*%s", c.formatType(t.X))
+	case *ast.ArrayType:
+		return fmt.Sprintf("[%s]%s", c.formatType(t.Len), c.formatType(t.Elt))
+	case *ast.Ellipsis:
+		return "..." + c.formatType(t.Elt)
+	case *ast.FuncType:
+		return fmt.Sprintf("func(%s)%s", c.formatParams(t.Params), c.formatFuncResults(t.Results))
+	case *ast.MapType:
+		return fmt.Sprintf("map[%s]%s", c.formatType(t.Key), c.formatType(t.Value))
+	case *ast.ChanType:
+		s := ""
+		if t.Dir == 1 {
+			s = "<-chan"
+		} else if t.Dir == 2 {
+			s = "chan<-"
+		} else if t.Dir == 3 {
+			s = "chan"
+		}
+		return fmt.Sprintf("%s %s", s, c.formatType(t.Value))
+	case *ast.BasicLit:
+		return t.Value
+	case *ast.StructType:
+		return c.formatStruct(t)
+	case *ast.InterfaceType:
+		return c.formatInterface(t)
+	case *ast.UnaryExpr:
+		return t.Op.String() + c.formatType(t.X)
+	case *ast.CompositeLit:
+		if c.ExpandLiterals {
+			return c.formatType(t.Type) + c.formatElements(t.Elts)
+		}
+		// abandon fields in {}
+		return c.formatType(t.Type) + "{}"
+	case *ast.KeyValueExpr:
+		return c.formatType(t.Key) + ": " + c.formatType(t.Value)
+	case *ast.CallExpr:
+		return c.formatType(t.Fun) + "()"
+	case *ast.BinaryExpr:
+		return fmt.Sprintf("%s %s %s", c.formatType(t.X), t.Op.String(), c.formatType(t.Y))
+	case *ast.FuncLit:
+		return c.formatType(t.Type)
+	case *ast.IndexExpr:
+		return fmt.Sprintf("%s[%s]", c.formatType(t.X), c.formatType(t.Index))
+	case *ast.IndexListExpr:
+		typ := []string{}
+		for _, expr := range t.Indices {
+			typ = append(typ, c.formatType(expr))
+		}
+		return fmt.Sprintf("%s[%s]", c.formatType(t.X), strings.Join(typ, ", "))
+	case *ast.ParenExpr:
+		return fmt.Sprintf("(%s)", c.formatType(t.X))
+	case *ast.SliceExpr:
+		s := c.formatType(t.X)
+		s += "["
+		if t.Low != nil {
+			s += c.formatType(t.Low)
+		}
+		s += ":"
+		if t.High != nil {
+			s += c.formatType(t.High)
+		}
+		if t.Slice3 {
+			s += ":"
+		}
+		if t.Max != nil {
+			s += c.formatType(t.Max)
+		}
+		s += "]"
+		return s
+	case *ast.TypeAssertExpr:
+		return fmt.Sprintf("%s.(%s)", c.formatType(t.X), c.formatType(t.Type))
+	default:
+		return fmt.Sprintf("unsupported type %#v", t)
+	}
+}
+
+// formatElements renders the elements of a composite literal on one line,
+// for Config.ExpandLiterals. Elements keyed by unexported field names are
+// not part of the API and are replaced by a single "...".
+func (c *Config) formatElements(elts []ast.Expr) string {
+	list := []string{}
+	hidden := false
+	for _, elt := range elts {
+		if kv, ok := elt.(*ast.KeyValueExpr); ok {
+			if key, ok := kv.Key.(*ast.Ident); ok && !isUpper0(key.Name) {
+				hidden = true
+				continue
+			}
+		}
+		list = append(list, c.formatType(elt))
+	}
+	if hidden {
+		list = append(list, "...")
+	}
+	return "{" + strings.Join(list, ", ") + "}"
+}
+
+// formatStruct renders the exported fields of a struct, one per line, in
+// the style of go doc. Nested struct types are indented recursively.
+func (c *Config) formatStruct(t *ast.StructType) string {
+	if c.Compact {
+		return "struct{}"
+	}
+	lines := []string{}
+	hidden := false
+	for _, field := range t.Fields.List {
+		if len(field.Names) == 0 { // embedded field
+			if !isUpper0(embeddedName(field.Type)) {
+				hidden = true
+				continue
+			}
+			line := c.formatType(field.Type)
+			if field.Tag != nil {
+				line += " " + formatTag(field.Tag.Value)
+			}
+			lines = append(lines, line)
+			continue
+		}
+		names := []string{}
+		for _, name := range field.Names {
+			if isUpper0(name.Name) {
+				names = append(names, name.Name)
+			}
+		}
+		if len(names) == 0 {
+			hidden = true
+			continue
+		}
+		if len(names) != len(field.Names) {
+			hidden = true
+		}
+		line := strings.Join(names, ", ") + " " + c.formatType(field.Type)
+		if field.Tag != nil {
+			line += " " + formatTag(field.Tag.Value)
+		}
+		lines = append(lines, line)
+	}
+	if hidden {
+		lines = append(lines, "// contains filtered or unexported fields")
+	}
+	if len(lines) == 0 {
+		return "struct{}"
+	}
+	return "struct " + formatBlock(lines)
+}
+
+// embeddedName returns the field name of an embedded field of type typ:
+// its type name without pointer, package qualifier or type arguments.
+func embeddedName(typ ast.Expr) string {
+	for {
+		switch t := typ.(type) {
+		case *ast.StarExpr:
+			typ = t.X
+		case *ast.SelectorExpr:
+			return t.Sel.Name
+		case *ast.IndexExpr:
+			typ = t.X
+		case *ast.IndexListExpr:
+			typ = t.X
+		case *ast.ParenExpr:
+			typ = t.X
+		case *ast.Ident:
+			return t.Name
+		default:
+			return ""
+		}
+	}
+}
+
+// formatTag writes a struct tag literal as a raw string, the way tags are
+// conventionally written, when it was written as an interpreted string.
+func formatTag(lit string) string {
+	if strings.HasPrefix(lit, "`") {
+		return lit
+	}
+	tag, err := strconv.Unquote(lit)
+	if err != nil || strings.ContainsAny(tag, "`\n") {
+		return lit
+	}
+	return "`" + tag + "`"
+}
+
+// formatInterface renders the exported methods and embedded interfaces or
+// type constraints of an interface, one per line.
+func (c *Config) formatInterface(t *ast.InterfaceType) string {
+	if c.Compact {
+		return "interface{}"
+	}
+	lines := []string{}
+	hidden := false
+	for _, field := range t.Methods.List {
+		if len(field.Names) == 0 {
+			lines = append(lines, c.formatType(field.Type))
+			continue
+		}
+		name := field.Names[0].Name
+		if !isUpper0(name) {
+			hidden = true
+			continue
+		}
+		if ft, ok := field.Type.(*ast.FuncType); ok {
+			lines = append(lines, fmt.Sprintf("%s(%s)%s", name, c.formatParams(ft.Params), c.formatFuncResults(ft.Results)))
+		}
+	}
+	if hidden {
+		lines = append(lines, "// contains filtered or unexported methods")
+	}
+	if len(lines) == 0 {
+		return "interface{}"
+	}
+	return "interface " + formatBlock(lines)
+}
+
+// formatBlock wraps lines in braces, indenting each line (including the
+// continuation lines of nested blocks) by one tab.
+func formatBlock(lines []string) string {
+	s := "{\n"
+	for _, line := range lines {
+		s += "\t" + strings.ReplaceAll(line, "\n", "\n\t") + "\n"
+	}
+	return s + "}"
+}
+
+func (c *Config) formatFuncResults(fields *ast.FieldList) string {
+	s := ""
+	if fields != nil {
+		s += " "
+		needPar := len(fields.List) > 1 || (len(fields.List) == 1 && len(fields.List[0].Names) > 0)
+		if c.OmitParamNames {
+			needPar = fields.NumFields() > 1
+		}
+		if needPar {
+			s += "("
+		}
+		s += c.formatParams(fields)
+		if needPar {
+			s += ")"
+		}
+	}
+	return s
+}
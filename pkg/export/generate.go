@@ -0,0 +1,96 @@
+package export
+
+import (
+	"bytes"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Directive is a //go:generate directive of a package's files.
+type Directive struct {
+	// Command is the text of the directive after "//go:generate", such
+	// as "stringer -type=Kind".
+	Command string `json:"command"`
+
+	// Tool is the program the command runs: the package or module of
+	// "go run", the tool of "go tool", that of the alias a -command
+	// directive defined, or else the first word of the command.
+	Tool string `json:"tool"`
+
+	// File is the name of the file holding the directive, and Line its
+	// line, starting at 1.
+	File string `json:"file"`
+	Line int    `json:"line"`
+}
+
+// generateDirectives returns the //go:generate directives of the Go files
+// of package pkgName in dir, test files included, in the order go
+// generate runs them: by file name, then line. Like go generate, it looks
+// for them at the start of any line, comments included.
+func (c *Config) generateDirectives(fsys fs.FS, dir, pkgName string) []*Directive {
+	list, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil
+	}
+	res := []*Directive{}
+	fset := token.NewFileSet()
+	for _, d := range list {
+		if d.IsDir() || !strings.HasSuffix(d.Name(), ".go") {
+			continue
+		}
+		if c.Context != nil && !matchFile(*c.Context, fsys, dir, d.Name()) {
+			continue
+		}
+		data, err := fs.ReadFile(fsys, path.Join(dir, d.Name()))
+		if err != nil || !bytes.Contains(data, []byte("//go:generate")) {
+			continue
+		}
+		src, err := parser.ParseFile(fset, filepath.Join(dir, d.Name()), data, parser.PackageClauseOnly)
+		if err != nil || src.Name.Name != pkgName && src.Name.Name != pkgName+"_test" {
+			continue
+		}
+		aliases := map[string]string{}
+		for i, text := range strings.Split(string(data), "\n") {
+			rest, ok := strings.CutPrefix(strings.TrimRight(text, "\r"), "//go:generate")
+			if !ok || rest != "" && rest[0] != ' ' && rest[0] != '\t' {
+				continue
+			}
+			command := strings.TrimSpace(rest)
+			if command == "" {
+				continue
+			}
+			res = append(res, &Directive{Command: command, Tool: generateTool(command, aliases), File: d.Name(), Line: i + 1})
+		}
+	}
+	return res
+}
+
+// generateTool returns the program a //go:generate command runs. aliases
+// maps the names defined by the -command directives seen so far in the
+// file to their programs, and gains the one command defines.
+func generateTool(command string, aliases map[string]string) string {
+	words := strings.Fields(command)
+	if words[0] == "-command" {
+		if len(words) < 3 {
+			return ""
+		}
+		tool := generateTool(strings.Join(words[2:], " "), aliases)
+		aliases[words[1]] = tool
+		return tool
+	}
+	if tool, ok := aliases[words[0]]; ok {
+		return tool
+	}
+	if words[0] == "go" && len(words) > 2 && (words[1] == "run" || words[1] == "tool") {
+		for _, w := range words[2:] {
+			if !strings.HasPrefix(w, "-") {
+				return w
+			}
+		}
+	}
+	return words[0]
+}
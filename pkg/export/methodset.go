@@ -0,0 +1,114 @@
+package export
+
+import (
+	"fmt"
+	"go/types"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Method is an exported method of a method set, with its types qualified
+// as seen from outside the declaring package.
+type Method struct {
+	Name     string
+	Params   []Param
+	Results  []Param
+	Variadic bool // the last parameter's Type is the element type of the ...
+}
+
+// Param is a parameter or result of a Method. Name is empty when the
+// declaration leaves it unnamed.
+type Param struct {
+	Name string
+	Type string
+}
+
+// Types returns the type-checked package, or nil if p was not parsed with
+// Config.Types.
+func (p *Package) Types() *types.Package {
+	return p.typesPkg
+}
+
+// MethodSet returns the exported methods that a value of type *typeName
+// has, including methods promoted from embedded fields, sorted by name.
+// For an interface type these are the methods of the interface. Types are
+// qualified by package name; imports maps the import path of every
+// package they refer to, p itself included, to the name used for it.
+//
+// MethodSet needs p to be parsed with Config.Types and its Path to be set.
+func (p *Package) MethodSet(typeName string) (methods []*Method, imports map[string]string, err error) {
+	if p.typesPkg == nil {
+		return nil, nil, fmt.Errorf("package %s was not type-checked", p.Path)
+	}
+	tn, _ := p.typesPkg.Scope().Lookup(typeName).(*types.TypeName)
+	if tn == nil || !tn.Exported() {
+		return nil, nil, fmt.Errorf("no exported type %s in package %s", typeName, p.Path)
+	}
+	typ := tn.Type()
+	if !types.IsInterface(typ) {
+		typ = types.NewPointer(typ)
+	}
+
+	imports = map[string]string{}
+	names := map[string]string{} // package name -> import path
+	qualifier := func(pkg *types.Package) string {
+		path := pkg.Path()
+		if pkg == p.typesPkg {
+			path = p.Path
+		}
+		if name, ok := imports[path]; ok {
+			return name
+		}
+		name := pkg.Name()
+		for i := 2; names[name] != ""; i++ {
+			name = pkg.Name() + strconv.Itoa(i)
+		}
+		imports[path] = name
+		names[name] = path
+		return name
+	}
+	var unresolved []string
+	typeString := func(fn *types.Func, t types.Type) string {
+		s := types.TypeString(t, qualifier)
+		if strings.Contains(s, "invalid type") && (len(unresolved) == 0 || unresolved[len(unresolved)-1] != fn.Name()) {
+			unresolved = append(unresolved, fn.Name())
+		}
+		return s
+	}
+	tuple := func(fn *types.Func, t *types.Tuple) []Param {
+		list := []Param{}
+		for i := 0; i < t.Len(); i++ {
+			v := t.At(i)
+			list = append(list, Param{Name: v.Name(), Type: typeString(fn, v.Type())})
+		}
+		return list
+	}
+
+	mset := types.NewMethodSet(typ)
+	for i := 0; i < mset.Len(); i++ {
+		fn, ok := mset.At(i).Obj().(*types.Func)
+		if !ok || !fn.Exported() {
+			continue
+		}
+		sig := fn.Type().(*types.Signature)
+		m := &Method{
+			Name:     fn.Name(),
+			Params:   tuple(fn, sig.Params()),
+			Results:  tuple(fn, sig.Results()),
+			Variadic: sig.Variadic(),
+		}
+		if m.Variadic {
+			last := sig.Params().At(sig.Params().Len() - 1)
+			if slice, ok := last.Type().(*types.Slice); ok {
+				m.Params[len(m.Params)-1].Type = typeString(fn, slice.Elem())
+			}
+		}
+		methods = append(methods, m)
+	}
+	if len(unresolved) > 0 {
+		return nil, nil, fmt.Errorf("cannot resolve the types of %s.%s (%s); are its imports resolvable from here?", p.Path, typeName, strings.Join(unresolved, ", "))
+	}
+	sort.Slice(methods, func(i, j int) bool { return methods[i].Name < methods[j].Name })
+	return methods, imports, nil
+}
@@ -0,0 +1,72 @@
+package export
+
+import (
+	"go/build"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+)
+
+// matchFile reports whether ctx selects the file dir/name of fsys.
+func matchFile(ctx build.Context, fsys fs.FS, dir, name string) bool {
+	ctx.JoinPath = path.Join
+	ctx.OpenFile = func(p string) (io.ReadCloser, error) {
+		return fsys.Open(p)
+	}
+	ok, err := ctx.MatchFile(dir, name)
+	return err == nil && ok
+}
+
+// filePlatforms returns the platforms among c.Platforms whose build
+// constraints the file dir/name of fsys satisfies. Cgo is assumed to be
+// available everywhere, so cgo files count for every platform they are
+// tagged for.
+func (c *Config) filePlatforms(fsys fs.FS, dir, name string) []string {
+	base := build.Default
+	if c.Context != nil {
+		base = *c.Context
+	}
+	base.CgoEnabled = true
+	res := []string{}
+	for _, p := range c.Platforms {
+		goos, goarch, _ := strings.Cut(p, "/")
+		ctx := base
+		ctx.GOOS, ctx.GOARCH = goos, goarch
+		if matchFile(ctx, fsys, dir, name) {
+			res = append(res, p)
+		}
+	}
+	return res
+}
+
+// condensePlatforms summarizes a subset of c.Platforms for display. It
+// returns nil when the subset covers every platform, and otherwise lists
+// a bare GOOS for operating systems whose architectures are all included
+// and "goos/goarch" for the others.
+func (c *Config) condensePlatforms(matched []string) []string {
+	if len(matched) == len(c.Platforms) {
+		return nil
+	}
+	archs := map[string]int{}
+	for _, p := range c.Platforms {
+		goos, _, _ := strings.Cut(p, "/")
+		archs[goos]++
+	}
+	matchedArchs := map[string][]string{}
+	for _, p := range matched {
+		goos, _, _ := strings.Cut(p, "/")
+		matchedArchs[goos] = append(matchedArchs[goos], p)
+	}
+	res := []string{}
+	for goos, list := range matchedArchs {
+		if len(list) == archs[goos] {
+			res = append(res, goos)
+		} else {
+			res = append(res, list...)
+		}
+	}
+	sort.Strings(res)
+	return res
+}
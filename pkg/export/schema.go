@@ -0,0 +1,14 @@
+package export
+
+import _ "embed"
+
+// SchemaVersion is the version of the JSON encoding of Package that Schema
+// describes. It changes when a field is removed, renamed or changes
+// meaning, not when optional fields are added.
+const SchemaVersion = "1"
+
+// Schema is the JSON Schema of the JSON encoding of Package, with a
+// "schema_version" field holding SchemaVersion added in front.
+//
+//go:embed schema.json
+var Schema []byte
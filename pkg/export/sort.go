@@ -0,0 +1,69 @@
+package export
+
+import (
+	"fmt"
+	"sort"
+)
+
+// SortOrder is an order of the symbols of a file, for Package.Sort.
+type SortOrder string
+
+const (
+	// SortSource is the order symbols are extracted in: declaration
+	// order, with the methods promoted into a struct type, and the
+	// constants of enums grouped by GroupEnums, following the type.
+	SortSource SortOrder = "source"
+
+	// SortPosition orders symbols strictly by their position in the
+	// file. Promoted methods, which have no position, stay after the
+	// type they are listed with.
+	SortPosition SortOrder = "position"
+
+	// SortName orders symbols by Key, so methods follow their type.
+	SortName SortOrder = "name"
+
+	// SortKind lists constants, variables, functions and then types,
+	// each type followed by its methods, ordered by Key within each
+	// kind.
+	SortKind SortOrder = "kind"
+)
+
+// kindRank orders the kinds for SortKind.
+var kindRank = map[Kind]int{KindConst: 0, KindVar: 1, KindFunc: 2, KindType: 3, KindMethod: 3}
+
+// ParseSortOrder returns the SortOrder named s.
+func ParseSortOrder(s string) (SortOrder, error) {
+	switch o := SortOrder(s); o {
+	case SortSource, SortPosition, SortName, SortKind:
+		return o, nil
+	}
+	return "", fmt.Errorf("unknown sort order %q; want source, position, name or kind", s)
+}
+
+// Sort reorders the symbols of each file of p. Files stay sorted by name.
+func (p *Package) Sort(order SortOrder) {
+	for _, f := range p.Files {
+		syms := f.Symbols
+		switch order {
+		case SortPosition:
+			offsets := make(map[*Symbol]int, len(syms))
+			last := 0
+			for _, sym := range syms {
+				if sym.Pos != nil {
+					last = sym.Pos.Offset
+				}
+				offsets[sym] = last
+			}
+			sort.SliceStable(syms, func(i, j int) bool { return offsets[syms[i]] < offsets[syms[j]] })
+		case SortName:
+			sort.SliceStable(syms, func(i, j int) bool { return syms[i].Key() < syms[j].Key() })
+		case SortKind:
+			sort.SliceStable(syms, func(i, j int) bool {
+				if ri, rj := kindRank[syms[i].Kind], kindRank[syms[j].Kind]; ri != rj {
+					return ri < rj
+				}
+				return syms[i].Key() < syms[j].Key()
+			})
+		}
+	}
+}
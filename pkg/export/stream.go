@@ -0,0 +1,117 @@
+package export
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// StreamDir is ParseDir for packages too large to hold in memory. It
+// parses the files one at a time, as with Config.LowMemory, and rather
+// than collecting their exports hands each file to fn as soon as it is
+// extracted, so that the caller can write it out and drop it. fn also
+// gets the package with the Name and Doc found so far. Files come in name
+// order, those of the external test package, with ExternalTests, last.
+// The returned package has no Files. Types and Jobs are ignored.
+func (c *Config) StreamDir(dir string, fn func(pkg *Package, f *File)) (*Package, error) {
+	return c.stream(os.DirFS(dir), ".", dir, fn)
+}
+
+// stream implements StreamDir for directory dir of fsys, reported as
+// displayDir like in parseFS.
+func (c *Config) stream(fsys fs.FS, dir, displayDir string, fn func(*Package, *File)) (*Package, error) {
+	names, _, hasAsm, err := c.goFiles(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+	pkg := &Package{
+		Dir:   displayDir,
+		Files: []*File{},
+	}
+	var parseErr *ParseError
+	var xtests []string
+	each := func(name string, emit func(cc *Config, src *ast.File)) error {
+		data, err := fs.ReadFile(fsys, path.Join(dir, name))
+		if err != nil {
+			return err
+		}
+		// A file set per file lets the positions of a file go with it.
+		fset := token.NewFileSet()
+		src, err := parser.ParseFile(fset, filepath.Join(displayDir, name), data, parser.ParseComments)
+		if err != nil {
+			if parseErr == nil {
+				parseErr = &ParseError{Dir: displayDir}
+			}
+			parseErr.Errs = append(parseErr.Errs, err)
+			return nil
+		}
+		if src.Name.Name == "main" || c.SkipCgo && importsC(src) || c.SkipGenerated && ast.IsGenerated(src) {
+			return nil
+		}
+		cc := *c
+		cc.fset = fset
+		cc.hasAsm = hasAsm
+		cc.typesPkg = nil
+		cc.constPkg = typeCheckLocal(fset, []*ast.File{src})
+		emit(&cc, src)
+		return nil
+	}
+	for _, name := range names {
+		if strings.HasSuffix(name, "_test.go") {
+			xtests = append(xtests, name)
+			continue
+		}
+		err := each(name, func(cc *Config, src *ast.File) {
+			if pkg.Name == "" {
+				pkg.Name = src.Name.Name
+			}
+			if c.Docs && pkg.Doc == "" && src.Doc != nil {
+				pkg.Doc = strings.TrimSpace(src.Doc.Text())
+			}
+			f := cc.FileExports(name, src)
+			if len(f.Symbols) == 0 {
+				return
+			}
+			if len(c.Platforms) > 0 {
+				platforms := c.condensePlatforms(c.filePlatforms(fsys, dir, name))
+				for _, sym := range f.Symbols {
+					sym.Platforms = platforms
+				}
+			}
+			fn(pkg, f)
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	if pkg.Name != "" {
+		for _, name := range xtests {
+			err := each(name, func(cc *Config, src *ast.File) {
+				if src.Name.Name != pkg.Name+"_test" {
+					return
+				}
+				if f := cc.xtestFile(name, src); len(f.Symbols) > 0 {
+					fn(pkg, f)
+				}
+			})
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	if c.Examples && pkg.Name != "" {
+		pkg.Examples = c.examples(fsys, dir, pkg.Name)
+	}
+	if c.Generate && pkg.Name != "" {
+		pkg.Generate = c.generateDirectives(fsys, dir, pkg.Name)
+	}
+	if parseErr != nil {
+		return pkg, parseErr
+	}
+	return pkg, nil
+}
@@ -0,0 +1,319 @@
+package export
+
+import (
+	"fmt"
+	"go/ast"
+	"go/constant"
+	"go/importer"
+	"go/token"
+	"go/types"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// typeCheck type-checks the files of one package, importing dependencies
+// with c.Importer or else from source. Type errors are ignored: the
+// checker still records every object it could resolve. The C
+// pseudo-package of cgo files is faked, leaving the C types invalid.
+func (c *Config) typeCheck(fset *token.FileSet, files []*ast.File) *types.Package {
+	imp := c.Importer
+	if imp == nil {
+		imp = importer.ForCompiler(fset, "source", nil)
+	}
+	conf := types.Config{
+		Importer:    imp,
+		Error:       func(err error) {},
+		FakeImportC: true,
+	}
+	pkg, _ := conf.Check(files[0].Name.Name, fset, files, nil)
+	return pkg
+}
+
+// typeCheckLocal type-checks the files of one package without importing
+// anything, which is enough to evaluate constants declared in terms of
+// each other. The C pseudo-package of cgo files is faked, as by
+// typeCheck.
+func typeCheckLocal(fset *token.FileSet, files []*ast.File) *types.Package {
+	conf := types.Config{
+		Importer:    noImporter{},
+		Error:       func(err error) {},
+		FakeImportC: true,
+	}
+	pkg, _ := conf.Check(files[0].Name.Name, fset, files, nil)
+	return pkg
+}
+
+type noImporter struct{}
+
+func (noImporter) Import(path string) (*types.Package, error) {
+	return nil, fmt.Errorf("%s not imported", path)
+}
+
+// constType returns the type of the package-level constant name when it
+// is typed without a type in its declaration, as in "A = T(1)".
+func (c *Config) constType(name string) string {
+	if c.constPkg == nil {
+		return ""
+	}
+	obj, ok := c.constPkg.Scope().Lookup(name).(*types.Const)
+	if !ok {
+		return ""
+	}
+	if b, ok := obj.Type().(*types.Basic); ok && b.Info()&types.IsUntyped != 0 {
+		return ""
+	}
+	return c.typeString(obj.Type())
+}
+
+// constValue returns the value of the package-level constant name, or ""
+// if it could not be evaluated. Integers and strings are written exactly,
+// floats to float64 precision.
+func (c *Config) constValue(name string) string {
+	if c.constPkg == nil {
+		return ""
+	}
+	obj, ok := c.constPkg.Scope().Lookup(name).(*types.Const)
+	if !ok {
+		return ""
+	}
+	val := obj.Val()
+	switch val.Kind() {
+	case constant.Unknown:
+		return ""
+	case constant.Int, constant.String:
+		return val.ExactString()
+	case constant.Float:
+		if f, _ := constant.Float64Val(val); !math.IsInf(f, 0) {
+			return strconv.FormatFloat(f, 'g', -1, 64)
+		}
+	}
+	return val.String()
+}
+
+// qualifier writes types of the checked package unqualified and types of
+// other packages qualified by their package name, as Go source would.
+func (c *Config) qualifier(p *types.Package) string {
+	if p == c.typesPkg || p == c.constPkg {
+		return ""
+	}
+	return p.Name()
+}
+
+// typeString formats t, or returns "" if t could not be fully resolved.
+func (c *Config) typeString(t types.Type) string {
+	s := types.TypeString(t, c.qualifier)
+	if strings.Contains(s, "invalid type") {
+		return ""
+	}
+	return s
+}
+
+// typesObjectType returns the resolved type of the package-level var or
+// const name. Untyped constants are left alone, since their type is only
+// decided where they are used.
+func (c *Config) typesObjectType(name string) string {
+	if c.typesPkg == nil {
+		return ""
+	}
+	obj := c.typesPkg.Scope().Lookup(name)
+	if obj == nil {
+		return ""
+	}
+	if b, ok := obj.Type().(*types.Basic); ok && b.Info()&types.IsUntyped != 0 {
+		return ""
+	}
+	return c.typeString(obj.Type())
+}
+
+// typesFuncSignature renders a function or method declaration from its
+// resolved signature. It returns "" when the package was not type-checked
+// or the signature could not be resolved.
+func (c *Config) typesFuncSignature(decl *ast.FuncDecl) string {
+	if c.typesPkg == nil {
+		return ""
+	}
+	fn := c.lookupFunc(decl)
+	if fn == nil {
+		return ""
+	}
+	sig := fn.Type().(*types.Signature)
+	s := "func "
+	if recv := sig.Recv(); recv != nil {
+		typ := c.typeString(recv.Type())
+		if typ == "" {
+			return ""
+		}
+		if recv.Name() == "" || recv.Name() == "_" || c.OmitParamNames {
+			s += "(" + typ + ") "
+		} else {
+			s += "(" + recv.Name() + " " + typ + ") "
+		}
+	}
+	s += fn.Name()
+	if tparams := sig.TypeParams(); tparams != nil && tparams.Len() > 0 {
+		list := []string{}
+		for i := 0; i < tparams.Len(); i++ {
+			tp := tparams.At(i)
+			list = append(list, tp.Obj().Name()+" "+c.typeString(tp.Constraint()))
+		}
+		s += "[" + strings.Join(list, ", ") + "]"
+	}
+	params, ok := c.formatTuple(sig.Params(), sig.Variadic())
+	if !ok {
+		return ""
+	}
+	s += "(" + params + ")"
+	results, ok := c.formatTuple(sig.Results(), false)
+	if !ok {
+		return ""
+	}
+	switch {
+	case sig.Results().Len() == 0:
+	case sig.Results().Len() == 1 && (sig.Results().At(0).Name() == "" || c.OmitParamNames):
+		s += " " + results
+	default:
+		s += " (" + results + ")"
+	}
+	return s
+}
+
+// lookupFunc finds the types object declared by decl.
+func (c *Config) lookupFunc(decl *ast.FuncDecl) *types.Func {
+	if decl.Recv == nil {
+		fn, _ := c.typesPkg.Scope().Lookup(decl.Name.Name).(*types.Func)
+		return fn
+	}
+	recv := decl.Recv.List[0].Type
+	for {
+		switch t := recv.(type) {
+		case *ast.StarExpr:
+			recv = t.X
+			continue
+		case *ast.IndexExpr:
+			recv = t.X
+			continue
+		case *ast.IndexListExpr:
+			recv = t.X
+			continue
+		case *ast.ParenExpr:
+			recv = t.X
+			continue
+		}
+		break
+	}
+	ident, ok := recv.(*ast.Ident)
+	if !ok {
+		return nil
+	}
+	tn, _ := c.typesPkg.Scope().Lookup(ident.Name).(*types.TypeName)
+	if tn == nil {
+		return nil
+	}
+	named, _ := tn.Type().(*types.Named)
+	if named == nil {
+		return nil
+	}
+	for i := 0; i < named.NumMethods(); i++ {
+		if m := named.Method(i); m.Name() == decl.Name.Name {
+			return m
+		}
+	}
+	return nil
+}
+
+// formatTuple formats a parameter or result list. Adjacent named
+// parameters of the same type are merged ("a, b int") as in source; names
+// are dropped with OmitParamNames.
+func (c *Config) formatTuple(tuple *types.Tuple, variadic bool) (string, bool) {
+	list := []string{}
+	names := []string{}
+	for i := 0; i < tuple.Len(); i++ {
+		v := tuple.At(i)
+		var typ string
+		if variadic && i == tuple.Len()-1 {
+			if slice, ok := v.Type().(*types.Slice); ok {
+				typ = c.typeString(slice.Elem())
+				if typ != "" {
+					typ = "..." + typ
+				}
+			}
+		} else {
+			typ = c.typeString(v.Type())
+		}
+		if typ == "" {
+			return "", false
+		}
+		if v.Name() == "" || c.OmitParamNames {
+			list = append(list, typ)
+			continue
+		}
+		names = append(names, v.Name())
+		if i+1 < tuple.Len() && !(variadic && i+1 == tuple.Len()-1) && types.Identical(v.Type(), tuple.At(i+1).Type()) {
+			continue
+		}
+		list = append(list, strings.Join(names, ", ")+" "+typ)
+		names = names[:0]
+	}
+	return strings.Join(list, ", "), true
+}
+
+// promotedMethods returns the exported methods that the struct type
+// typeName gains from its embedded fields, as method symbols. They are
+// only known when the package was type-checked with Types set.
+func (c *Config) promotedMethods(typeName string) []*Symbol {
+	if c.typesPkg == nil {
+		return nil
+	}
+	tn, _ := c.typesPkg.Scope().Lookup(typeName).(*types.TypeName)
+	if tn == nil {
+		return nil
+	}
+	named, _ := tn.Type().(*types.Named)
+	if named == nil {
+		return nil
+	}
+	if _, ok := named.Underlying().(*types.Struct); !ok {
+		return nil
+	}
+	res := []*Symbol{}
+	valueSet := types.NewMethodSet(named)
+	ptrSet := types.NewMethodSet(types.NewPointer(named))
+	for i := 0; i < ptrSet.Len(); i++ {
+		sel := ptrSet.At(i)
+		fn, ok := sel.Obj().(*types.Func)
+		if !ok || !fn.Exported() || len(sel.Index()) < 2 {
+			continue
+		}
+		sig := fn.Type().(*types.Signature)
+		origin := c.typeString(sig.Recv().Type())
+		params, ok := c.formatTuple(sig.Params(), sig.Variadic())
+		if !ok || origin == "" {
+			continue
+		}
+		results, ok := c.formatTuple(sig.Results(), false)
+		if !ok {
+			continue
+		}
+		recv := "*" + typeName
+		if valueSet.Lookup(fn.Pkg(), fn.Name()) != nil {
+			recv = typeName
+		}
+		s := fmt.Sprintf("func (%s) %s(%s)", recv, fn.Name(), params)
+		switch {
+		case sig.Results().Len() == 0:
+		case sig.Results().Len() == 1 && (sig.Results().At(0).Name() == "" || c.OmitParamNames):
+			s += " " + results
+		default:
+			s += " (" + results + ")"
+		}
+		res = append(res, &Symbol{
+			Kind:      KindMethod,
+			Name:      fn.Name(),
+			Receiver:  recv,
+			Signature: s,
+			Promoted:  origin,
+		})
+	}
+	return res
+}
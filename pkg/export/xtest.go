@@ -0,0 +1,58 @@
+package export
+
+import (
+	"go/ast"
+	"go/token"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// xtestExports returns the exported helpers of the files of the external
+// test package of package pkgName.
+func (c *Config) xtestExports(fset *token.FileSet, names []string, files []*ast.File, pkgName string) []*File {
+	cc := *c
+	cc.fset = fset
+	cc.typesPkg = nil
+	cc.constPkg = typeCheckLocal(fset, files)
+	res := []*File{}
+	for i, src := range files {
+		if src.Name.Name != pkgName+"_test" {
+			continue
+		}
+		if f := cc.xtestFile(names[i], src); len(f.Symbols) > 0 {
+			res = append(res, f)
+		}
+	}
+	return res
+}
+
+// xtestFile returns the exported helpers of a file of an external test
+// package, leaving out the functions go test runs.
+func (c *Config) xtestFile(name string, src *ast.File) *File {
+	f := c.FileExports(name, src)
+	f.Package = src.Name.Name
+	syms := f.Symbols[:0]
+	for _, sym := range f.Symbols {
+		if sym.Kind != KindFunc || !isTestFuncName(sym.Name) {
+			syms = append(syms, sym)
+		}
+	}
+	f.Symbols = syms
+	return f
+}
+
+// isTestFuncName reports whether a function of a test file is named like
+// the test, benchmark, fuzz and example functions go test runs.
+func isTestFuncName(name string) bool {
+	for _, prefix := range []string{"Test", "Benchmark", "Fuzz", "Example"} {
+		rest, ok := strings.CutPrefix(name, prefix)
+		if !ok {
+			continue
+		}
+		if r, _ := utf8.DecodeRuneInString(rest); rest == "" || !unicode.IsLower(r) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strings"
+
+	"github/urie96/go-list-export/pkg/export"
+)
+
+// protoField is a field of a protobuf message following the model of
+// pkg/export/package.proto. value is a string, a bool, a protoEnum or,
+// for a nested message, a []protoField. Repeated fields occur once per
+// element, and fields with their zero value are left out as in proto3.
+type protoField struct {
+	num   int
+	name  string
+	value any
+}
+
+// protoEnum is the value of an enum field.
+type protoEnum struct {
+	num  int
+	name string
+}
+
+// protoKinds maps symbol kinds to the values of the Kind enum.
+var protoKinds = map[export.Kind]protoEnum{
+	export.KindFunc:   {1, "KIND_FUNC"},
+	export.KindMethod: {2, "KIND_METHOD"},
+	export.KindType:   {3, "KIND_TYPE"},
+	export.KindVar:    {4, "KIND_VAR"},
+	export.KindConst:  {5, "KIND_CONST"},
+}
+
+// packageProto returns the fields of the Package message of pkg.
+func packageProto(pkg *export.Package) []protoField {
+	var m []protoField
+	add := func(num int, name string, value any) {
+		m = append(m, protoField{num, name, value})
+	}
+	add(1, "path", pkg.Path)
+	add(2, "name", pkg.Name)
+	add(3, "module", pkg.Module)
+	add(4, "version", pkg.Version)
+	add(5, "dir", pkg.Dir)
+	add(6, "doc", pkg.Doc)
+	for _, f := range pkg.Files {
+		add(7, "files", fileProto(f))
+	}
+	for _, ex := range pkg.Examples {
+		add(8, "examples", []protoField{
+			{1, "name", ex.Name},
+			{2, "symbol", ex.Symbol},
+			{3, "suffix", ex.Suffix},
+			{4, "file", ex.File},
+		})
+	}
+	for _, d := range pkg.Generate {
+		add(9, "generate", []protoField{
+			{1, "command", d.Command},
+			{2, "tool", d.Tool},
+			{3, "file", d.File},
+			{4, "line", d.Line},
+		})
+	}
+	return m
+}
+
+func fileProto(f *export.File) []protoField {
+	m := []protoField{{1, "name", f.Name}, {2, "package", f.Package}}
+	for _, sym := range f.Symbols {
+		m = append(m, protoField{3, "symbols", symbolProto(sym)})
+	}
+	return append(m, protoField{4, "generated", f.Generated})
+}
+
+func symbolProto(sym *export.Symbol) []protoField {
+	m := []protoField{
+		{1, "kind", protoKinds[sym.Kind]},
+		{2, "name", sym.Name},
+		{3, "receiver", sym.Receiver},
+		{4, "signature", []protoField{{1, "text", sym.Signature}}},
+		{5, "doc", sym.Doc},
+		{6, "deprecated", sym.Deprecated},
+	}
+	for _, p := range sym.Platforms {
+		m = append(m, protoField{7, "platforms", p})
+	}
+	return append(m,
+		protoField{8, "position", sym.Position},
+		protoField{9, "url", sym.URL},
+		protoField{10, "promoted", sym.Promoted},
+		protoField{11, "nobody", sym.NoBody},
+		protoField{12, "internal", sym.Internal},
+		protoField{13, "cgo", sym.Cgo},
+		protoField{14, "enum", sym.Enum},
+		protoField{15, "stability", sym.Stability},
+	)
+}
+
+// writeProtobuf writes pkg in the protobuf wire format as the packages
+// field of a PackageList. Since repeated fields concatenate, the output of
+// several packages is a single valid PackageList.
+func writeProtobuf(w io.Writer, pkg *export.Package, header bool) {
+	data := appendProtoField(nil, protoField{1, "packages", packageProto(pkg)})
+	if _, err := w.Write(data); err != nil {
+		fatal(err)
+	}
+}
+
+// appendProtoField appends the wire encoding of f to b, or nothing if f
+// holds the zero value of a scalar.
+func appendProtoField(b []byte, f protoField) []byte {
+	const varint, bytes = 0, 2
+	switch v := f.value.(type) {
+	case string:
+		if v == "" {
+			return b
+		}
+		b = binary.AppendUvarint(b, uint64(f.num<<3|bytes))
+		b = binary.AppendUvarint(b, uint64(len(v)))
+		return append(b, v...)
+	case bool:
+		if !v {
+			return b
+		}
+		b = binary.AppendUvarint(b, uint64(f.num<<3|varint))
+		return append(b, 1)
+	case int:
+		if v == 0 {
+			return b
+		}
+		b = binary.AppendUvarint(b, uint64(f.num<<3|varint))
+		return binary.AppendUvarint(b, uint64(v))
+	case protoEnum:
+		if v.num == 0 {
+			return b
+		}
+		b = binary.AppendUvarint(b, uint64(f.num<<3|varint))
+		return binary.AppendUvarint(b, uint64(v.num))
+	case []protoField:
+		var msg []byte
+		for _, sub := range v {
+			msg = appendProtoField(msg, sub)
+		}
+		b = binary.AppendUvarint(b, uint64(f.num<<3|bytes))
+		b = binary.AppendUvarint(b, uint64(len(msg)))
+		return append(b, msg...)
+	}
+	panic(fmt.Sprintf("protobuf: unsupported value %T", f.value))
+}
+
+// writeTextproto writes pkg in the protobuf text format as the packages
+// field of a PackageList, which like writeProtobuf's output concatenates.
+func writeTextproto(w io.Writer, pkg *export.Package, header bool) {
+	writeTextprotoField(w, "", protoField{1, "packages", packageProto(pkg)})
+}
+
+func writeTextprotoField(w io.Writer, indent string, f protoField) {
+	switch v := f.value.(type) {
+	case string:
+		if v != "" {
+			fmt.Fprintf(w, "%s%s: %s\n", indent, f.name, textprotoQuote(v))
+		}
+	case bool:
+		if v {
+			fmt.Fprintf(w, "%s%s: true\n", indent, f.name)
+		}
+	case int:
+		if v != 0 {
+			fmt.Fprintf(w, "%s%s: %d\n", indent, f.name, v)
+		}
+	case protoEnum:
+		if v.num != 0 {
+			fmt.Fprintf(w, "%s%s: %s\n", indent, f.name, v.name)
+		}
+	case []protoField:
+		fmt.Fprintf(w, "%s%s {\n", indent, f.name)
+		for _, sub := range v {
+			writeTextprotoField(w, indent+"  ", sub)
+		}
+		fmt.Fprintf(w, "%s}\n", indent)
+	}
+}
+
+// textprotoQuote quotes s as a text format string literal. Bytes outside
+// printable ASCII other than UTF-8 sequences are escaped in octal.
+func textprotoQuote(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '"' || c == '\\':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case c == '\n':
+			b.WriteString(`\n`)
+		case c == '\t':
+			b.WriteString(`\t`)
+		case c < 0x20 || c == 0x7f:
+			fmt.Fprintf(&b, `\%03o`, c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
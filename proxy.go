@@ -0,0 +1,140 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github/urie96/go-list-export/pkg/export"
+)
+
+// errProxyNotFound is returned by proxyGet when the proxy does not know
+// the requested module or version.
+var errProxyNotFound = errors.New("not found on proxy")
+
+var proxyClient = &http.Client{Timeout: 2 * time.Minute}
+
+// proxyURLs returns the module proxies listed in GOPROXY, in order.
+// "direct" entries are skipped since they need a version control tool,
+// and an "off" entry ends the list.
+func proxyURLs() []string {
+	env := os.Getenv("GOPROXY")
+	if env == "" {
+		env = "https://proxy.golang.org,direct"
+	}
+	urls := []string{}
+	for _, u := range strings.FieldsFunc(env, func(r rune) bool { return r == ',' || r == '|' }) {
+		u = strings.TrimSpace(u)
+		if u == "off" {
+			break
+		}
+		if u == "direct" || u == "" {
+			continue
+		}
+		urls = append(urls, strings.TrimSuffix(u, "/"))
+	}
+	return urls
+}
+
+// proxyGet fetches one file of the module proxy protocol (e.g. "@latest"
+// or "@v/v1.2.3.zip") for modPath, trying each proxy until one has it.
+func proxyGet(modPath, file string) ([]byte, error) {
+	urls := proxyURLs()
+	if len(urls) == 0 {
+		return nil, errors.New("GOPROXY lists no usable proxy")
+	}
+	for _, base := range urls {
+		resp, err := proxyClient.Get(base + "/" + escapeModulePath(modPath) + "/" + file)
+		if err != nil {
+			return nil, err
+		}
+		data, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		switch resp.StatusCode {
+		case http.StatusOK:
+			return data, nil
+		case http.StatusNotFound, http.StatusGone: // try the next proxy
+		default:
+			return nil, fmt.Errorf("%s: %s", base, resp.Status)
+		}
+	}
+	return nil, errProxyNotFound
+}
+
+// proxyVersion resolves a version query ("latest", a branch, a canonical
+// version) of modPath to a canonical version.
+func proxyVersion(modPath, query string) (string, error) {
+	file := "@latest"
+	if query != "latest" {
+		file = "@v/" + escapeModulePath(query) + ".info"
+	}
+	data, err := proxyGet(modPath, file)
+	if err != nil {
+		return "", err
+	}
+	var info struct{ Version string }
+	if err := json.Unmarshal(data, &info); err != nil {
+		return "", err
+	}
+	return info.Version, nil
+}
+
+// loadFromProxy lists an import path (optionally with @version) from the
+// module proxy without using the go command or GOMODCACHE. The module zip
+// is read in memory. The module is the longest prefix of the import path
+// the proxy knows about; since some proxies answer unknown paths with
+// errors other than 404, such errors only count if no prefix resolves.
+func loadFromProxy(cfg *export.Config, arg string) (*export.Package, error) {
+	importPath, query := splitVersion(arg)
+	if query == "" {
+		query = "latest"
+	}
+	var firstErr error
+	for modPath := importPath; modPath != "." && modPath != "/"; modPath = path.Dir(modPath) {
+		version, err := proxyVersion(modPath, query)
+		if err != nil {
+			if firstErr == nil && !errors.Is(err, errProxyNotFound) {
+				firstErr = err
+			}
+			continue
+		}
+		data, err := proxyGet(modPath, "@v/"+escapeModulePath(version)+".zip")
+		if err != nil {
+			return nil, err
+		}
+		zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			return nil, err
+		}
+		return parseModuleZip(cfg, zr, arg, modPath, version)
+	}
+	if firstErr != nil {
+		return nil, &notFoundError{arg: arg, reason: firstErr.Error()}
+	}
+	return nil, &notFoundError{arg: arg, reason: "module not found on GOPROXY"}
+}
+
+// parseModuleZip parses the package of arg from the zip of module
+// modPath@version. Files in module zips are stored under a
+// "modPath@version/" prefix.
+func parseModuleZip(cfg *export.Config, zr *zip.Reader, arg, modPath, version string) (*export.Package, error) {
+	importPath, _ := splitVersion(arg)
+	dir := path.Join(modPath+"@"+version, strings.TrimPrefix(importPath, modPath))
+	pkg, err := cfg.ParseFS(zr, dir)
+	if pkg == nil {
+		return nil, &notFoundError{arg: arg, reason: fmt.Sprintf("no package in module %s@%s", modPath, version)}
+	}
+	pkg.Path, pkg.Module, pkg.Version = importPath, modPath, version
+	return pkg, err
+}
@@ -0,0 +1,265 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/doc"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github/urie96/go-list-export/pkg/export"
+)
+
+// releaseNote is an entry of the release notes: a symbol with the first
+// sentence of its doc, or for a deprecated symbol its deprecation notice.
+type releaseNote struct {
+	Symbol    string      `json:"symbol"`
+	Kind      export.Kind `json:"kind"`
+	Signature string      `json:"signature"`
+	Summary   string      `json:"summary,omitempty"`
+	Breaking  bool        `json:"breaking,omitempty"`
+}
+
+// packageNotes are the release notes of one package. Status is "added" or
+// "removed" for a package present in only one of the revisions.
+type packageNotes struct {
+	Path       string        `json:"path"`
+	Status     string        `json:"status,omitempty"`
+	Added      []releaseNote `json:"added,omitempty"`
+	Changed    []releaseNote `json:"changed,omitempty"`
+	Removed    []releaseNote `json:"removed,omitempty"`
+	Deprecated []releaseNote `json:"deprecated,omitempty"`
+}
+
+// runReleaseNotes implements `go-list-export release-notes OLD..NEW
+// [packages]`. It compares the packages, ./... by default, at two
+// revisions of the git repository holding them, NEW being the working
+// tree when left out, and drafts the "New APIs", "Changed", "Removed" and
+// "Deprecated" sections of the release notes as markdown, each symbol
+// with the first sentence of its doc.
+func runReleaseNotes(cwd string, args []string) {
+	if len(args) < 1 || len(args) > 2 {
+		usageError("release-notes: usage: release-notes OLD..[NEW] [packages]")
+	}
+	oldRev, newRev, ok := strings.Cut(args[0], "..")
+	if !ok || oldRev == "" {
+		usageError("release-notes: revision range %s is not of the form OLD..NEW", args[0])
+	}
+	pattern := "./..."
+	if len(args) == 2 {
+		pattern = args[1]
+	}
+	root := pattern
+	if isPattern(pattern) {
+		root = patternRoot(pattern)
+	}
+	rootDir, err := resolveDir(root, cwd)
+	if err != nil {
+		fatal(err)
+	}
+	if dir, err := filepath.EvalSymlinks(rootDir); err == nil {
+		rootDir = dir
+	}
+	top, err := gitToplevel(rootDir)
+	if err != nil {
+		fatal(err)
+	}
+	prefix, err := filepath.Rel(top, rootDir)
+	if err != nil {
+		fatal(err)
+	}
+	oldFS, err := gitTree(top, oldRev, prefix)
+	if err != nil {
+		fatal(err)
+	}
+	newFS := os.DirFS(rootDir)
+	if newRev != "" {
+		if newFS, err = gitTree(top, newRev, prefix); err != nil {
+			fatal(err)
+		}
+	}
+
+	base := root
+	if mod := moduleRoot(rootDir); mod != "" {
+		if rel, err := filepath.Rel(mod, rootDir); err == nil {
+			base = joinImportPath(modulePath(filepath.Join(mod, "go.mod")), filepath.ToSlash(rel))
+		}
+	}
+	cfg := newConfig()
+	cfg.OmitParamNames = true
+	cfg.Docs = true
+	oldPkgs := revisionPackages(cfg, oldFS, base, isPattern(pattern))
+	newPkgs := revisionPackages(cfg, newFS, base, isPattern(pattern))
+
+	rels := []string{}
+	for rel := range oldPkgs {
+		rels = append(rels, rel)
+	}
+	for rel := range newPkgs {
+		if oldPkgs[rel] == nil {
+			rels = append(rels, rel)
+		}
+	}
+	sort.Strings(rels)
+	notes := []packageNotes{}
+	for _, rel := range rels {
+		oldPkg, newPkg := oldPkgs[rel], newPkgs[rel]
+		pn := packageNotes{Path: joinImportPath(base, rel)}
+		switch {
+		case oldPkg == nil:
+			pn.Status, oldPkg = "added", &export.Package{}
+		case newPkg == nil:
+			pn.Status, newPkg = "removed", &export.Package{}
+		}
+		for _, c := range export.Diff(oldPkg, newPkg) {
+			switch c.Kind {
+			case export.Added:
+				pn.Added = append(pn.Added, noteFor(c.New, c.New.Doc, false))
+			case export.Removed:
+				pn.Removed = append(pn.Removed, noteFor(c.Old, c.Old.Doc, true))
+			case export.Changed:
+				pn.Changed = append(pn.Changed, noteFor(c.New, c.New.Doc, c.Breaking))
+			}
+		}
+		oldSyms := map[string]*export.Symbol{}
+		for _, sym := range oldPkg.Symbols() {
+			oldSyms[sym.Key()] = sym
+		}
+		for _, sym := range newPkg.Symbols() {
+			if old := oldSyms[sym.Key()]; sym.Deprecated != "" && (old == nil || old.Deprecated == "") {
+				pn.Deprecated = append(pn.Deprecated, noteFor(sym, sym.Deprecated, false))
+			}
+		}
+		if pn.Status != "" || len(pn.Added)+len(pn.Changed)+len(pn.Removed)+len(pn.Deprecated) > 0 {
+			notes = append(notes, pn)
+		}
+	}
+
+	if *outputFormat == "json" {
+		encodeJSON(notes)
+		return
+	}
+	to := newRev
+	if to == "" {
+		to = "the working tree"
+	}
+	fmt.Printf("# Release notes: %s to %s\n", oldRev, to)
+	if len(notes) == 0 {
+		fmt.Printf("\nNo API changes.\n")
+		return
+	}
+	writeNoteSection("New APIs", notes, func(pn packageNotes) []releaseNote { return pn.Added })
+	writeNoteSection("Changed", notes, func(pn packageNotes) []releaseNote { return pn.Changed })
+	writeNoteSection("Removed", notes, func(pn packageNotes) []releaseNote { return pn.Removed })
+	writeNoteSection("Deprecated", notes, func(pn packageNotes) []releaseNote { return pn.Deprecated })
+}
+
+// noteFor makes the release note of sym, summarized by the first sentence
+// of text.
+func noteFor(sym *export.Symbol, text string, breaking bool) releaseNote {
+	return releaseNote{
+		Symbol:    sym.Key(),
+		Kind:      sym.Kind,
+		Signature: sym.Signature,
+		Summary:   new(doc.Package).Synopsis(text),
+		Breaking:  breaking,
+	}
+}
+
+// writeNoteSection prints a section of the release notes, with a
+// subheading for every package that has entries in it. Added and removed
+// packages are flagged in their subheading.
+func writeNoteSection(heading string, notes []packageNotes, entries func(packageNotes) []releaseNote) {
+	printed := false
+	for _, pn := range notes {
+		list := entries(pn)
+		if len(list) == 0 {
+			continue
+		}
+		if !printed {
+			fmt.Printf("\n## %s\n", heading)
+			printed = true
+		}
+		switch {
+		case pn.Status == "added" && heading == "New APIs":
+			fmt.Printf("\n### `%s` (new package)\n\n", pn.Path)
+		case pn.Status == "removed" && heading == "Removed":
+			fmt.Printf("\n### `%s` (package removed)\n\n", pn.Path)
+		default:
+			fmt.Printf("\n### `%s`\n\n", pn.Path)
+		}
+		for _, n := range list {
+			line := "- `" + n.Symbol + "`"
+			if n.Breaking && heading == "Changed" {
+				line += " (breaking)"
+			}
+			if n.Summary != "" {
+				line += ": " + n.Summary
+			}
+			fmt.Println(line)
+		}
+	}
+}
+
+// revisionPackages parses the packages of a revision tree, keyed by their
+// slash-separated directory below its root. With tree unset only the root
+// package is parsed.
+func revisionPackages(cfg *export.Config, fsys fs.FS, base string, tree bool) map[string]*export.Package {
+	rels := []string{"."}
+	if tree {
+		var err error
+		if rels, err = walkPackageDirsFS(fsys, *maxDepth); err != nil {
+			report(err)
+		}
+		rels = dropExcluded(base, dropInternal(rels))
+	}
+	pkgs := map[string]*export.Package{}
+	for _, rel := range rels {
+		pkg, err := cfg.ParseFS(fsys, rel)
+		if err != nil {
+			report(err)
+		}
+		if pkg == nil || pkg.Name == "" {
+			continue
+		}
+		filterPackage(pkg)
+		pkgs[rel] = pkg
+	}
+	return pkgs
+}
+
+// gitToplevel returns the root of the git work tree holding dir.
+func gitToplevel(dir string) (string, error) {
+	out, err := exec.Command("git", "-C", dir, "rev-parse", "--show-toplevel").Output()
+	if err != nil {
+		return "", fmt.Errorf("%s is not in a git work tree", dir)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// gitTree returns the files of directory dir, relative to the top of the
+// git work tree, as of revision rev, rooted at dir.
+func gitTree(top, rev, dir string) (fs.FS, error) {
+	args := []string{"-C", top, "archive", "--format=tar", rev}
+	if dir != "." {
+		args = append(args, "--", filepath.ToSlash(dir))
+	}
+	out, err := exec.Command("git", args...).Output()
+	if err != nil {
+		var ee *exec.ExitError
+		if errors.As(err, &ee) && len(ee.Stderr) > 0 {
+			return nil, &notFoundError{arg: rev, reason: strings.TrimSpace(string(ee.Stderr))}
+		}
+		return nil, err
+	}
+	files, err := tarFiles(bytes.NewReader(out))
+	if err != nil || dir == "." {
+		return files, err
+	}
+	return fs.Sub(files, filepath.ToSlash(dir))
+}
@@ -0,0 +1,364 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/build"
+	"go/token"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+	"unicode"
+)
+
+// resolveDir finds the source directory of an argument. An existing
+// directory is used as is. Other arguments are import paths, looked up in
+// the modules of the go.work workspace or in the vendor directory of the
+// current module if they provide the package, and otherwise with go/build,
+// falling back to a search of GOMODCACHE when go/build cannot resolve it.
+// Standard library packages are looked up in GOROOT directly, ahead of
+// directories of the same name. A "path@version" argument is resolved to
+// exactly that module version. With --dir every argument is a directory.
+// resolveDir never downloads; see downloadDir.
+func resolveDir(arg, cwd string) (string, error) {
+	if *dirArgs {
+		if dir := localDir(arg, cwd); dir != "" {
+			return dir, nil
+		}
+		return "", &notFoundError{arg: arg, reason: "no such directory"}
+	}
+	importPath, version := splitVersion(arg)
+	if dir := stdPackageDir(importPath); dir != "" {
+		if version != "" {
+			return "", &notFoundError{arg: arg, reason: "standard library packages have no versions"}
+		}
+		return dir, nil
+	}
+	if version != "" {
+		packagePath := cachedVersionDir(importPath, version)
+		if packagePath == "" {
+			return "", &notFoundError{arg: arg}
+		}
+		return packagePath, nil
+	}
+	if dir := localDir(importPath, cwd); dir != "" {
+		return dir, nil
+	}
+
+	packagePath := workspaceDir(importPath, cwd)
+	if packagePath == "" {
+		packagePath = vendorDir(importPath, cwd)
+	}
+	if packagePath == "" {
+		packagePath = getPackagePath(importPath, cwd)
+	}
+	if packagePath == "" {
+		packagePath = searchPackagePathFromGoModCache(importPath)
+		if packagePath != "" {
+			fmt.Fprintf(os.Stderr, "// `go list` failed, fallback to search GOMODCACHE: %s\n", packagePath)
+		}
+	}
+	if packagePath == "" {
+		return "", &notFoundError{arg: importPath}
+	}
+	return packagePath, nil
+}
+
+// downloadDir downloads the module holding an argument that resolveDir
+// could not find: the requested version of a "path@version" argument, or
+// with --download the latest version of a plain import path.
+func downloadDir(arg string) (string, error) {
+	importPath, version := splitVersion(arg)
+	if version == "" {
+		if !*download {
+			return "", &notFoundError{arg: importPath}
+		}
+		fmt.Fprintf(os.Stderr, "// %s not in GOMODCACHE, downloading the latest version\n", importPath)
+		version = "latest"
+	}
+	packagePath := downloadVersionDir(importPath, version)
+	if packagePath == "" {
+		return "", &notFoundError{arg: arg}
+	}
+	return packagePath, nil
+}
+
+// resolveOrDownloadDir is resolveDir followed, if need be, by downloadDir.
+func resolveOrDownloadDir(arg, cwd string) (string, error) {
+	dir, err := resolveDir(arg, cwd)
+	var nf *notFoundError
+	if errors.As(err, &nf) && !*dirArgs && stdPackageDir(arg) == "" {
+		return downloadDir(arg)
+	}
+	return dir, err
+}
+
+func goModCache() string {
+	gomodcache := os.Getenv("GOMODCACHE")
+	if gomodcache != "" {
+		return gomodcache
+	}
+	gopath := build.Default.GOPATH
+	list := filepath.SplitList(gopath)
+	if len(list) == 0 || list[0] == "" {
+		return ""
+	}
+	return filepath.Join(list[0], "pkg/mod")
+}
+
+// versionFromDir returns the module version encoded in a GOMODCACHE
+// directory name (e.g. "v1.2.3" for ".../foo@v1.2.3/bar"), the Go
+// release for directories of the standard library, or the version
+// recorded in vendor/modules.txt for vendored packages.
+func versionFromDir(dir string) string {
+	if isGoRootDir(dir) {
+		return goRootVersion()
+	}
+	if mod := vendorModule(dir); mod != nil {
+		return mod.Version
+	}
+	rel, err := filepath.Rel(goModCache(), dir)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return ""
+	}
+	for _, name := range strings.Split(rel, string(os.PathSeparator)) {
+		if i := strings.Index(name, "@"); i >= 0 {
+			return name[i+1:]
+		}
+	}
+	return ""
+}
+
+// moduleFromDir returns the path of the module a package directory belongs
+// to: "std" for the standard library, the module encoded in GOMODCACHE
+// directory names or recorded in vendor/modules.txt, and otherwise the
+// module of the enclosing go.mod file. It returns "" for directories
+// outside any module.
+func moduleFromDir(dir string) string {
+	if isGoRootDir(dir) {
+		return "std"
+	}
+	if mod := vendorModule(dir); mod != nil {
+		return mod.Path
+	}
+	if rel, err := filepath.Rel(goModCache(), dir); err == nil && !strings.HasPrefix(rel, "..") {
+		elems := []string{}
+		for _, name := range strings.Split(rel, string(os.PathSeparator)) {
+			if i := strings.Index(name, "@"); i >= 0 {
+				return unescapeModulePath(strings.Join(append(elems, name[:i]), "/"))
+			}
+			elems = append(elems, name)
+		}
+	}
+	if root := moduleRoot(dir); root != "" {
+		return modulePath(filepath.Join(root, "go.mod"))
+	}
+	return ""
+}
+
+// searchPackagePathFromGoModCache returns the preferred GOMODCACHE
+// directory for importPath, or "" if the module is not cached.
+func searchPackagePathFromGoModCache(importPath string) string {
+	candidates := goModCacheCandidates(importPath)
+	if len(candidates) == 0 {
+		return ""
+	}
+	return candidates[0]
+}
+
+// goModCacheCandidates returns every GOMODCACHE directory that holds
+// importPath, most preferred first. Longer module paths win over shorter
+// ones (as in the go command), and for a given module path the newest
+// release is preferred over older releases and prereleases.
+func goModCacheCandidates(importPath string) []string {
+	pa := goModCache()
+	if _, err := os.Stat(pa); err != nil {
+		return nil
+	}
+	return searchGoModCacheDir(pa, strings.Split(importPath, "/"), false)
+}
+
+// searchGoModCacheDir matches the remaining import path elements below dir.
+// inModule records whether a "name@version" directory has been entered;
+// directories outside any module version are not packages.
+func searchGoModCacheDir(dir string, elems []string, inModule bool) []string {
+	if len(elems) == 0 {
+		if !inModule {
+			return nil
+		}
+		return []string{dir}
+	}
+	list, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	name := escapeModulePath(elems[0])
+	res := []string{}
+	versions := []string{}
+	for _, f := range list {
+		if !f.IsDir() {
+			continue
+		}
+		if f.Name() == name {
+			res = append(res, searchGoModCacheDir(filepath.Join(dir, name), elems[1:], inModule)...)
+		} else if v, ok := strings.CutPrefix(f.Name(), name+"@"); ok {
+			versions = append(versions, v)
+		}
+	}
+	sortVersionsPreferred(versions)
+	for _, v := range versions {
+		res = append(res, searchGoModCacheDir(filepath.Join(dir, name+"@"+v), elems[1:], true)...)
+	}
+	return res
+}
+
+func getPackagePath(importPath, fromDir string) string {
+	pack, err := build.Default.Import(importPath, fromDir, build.FindOnly)
+	if err != nil {
+		return ""
+	}
+	return pack.Dir
+}
+
+// localDir returns arg as an absolute directory if it names an existing
+// directory, relative to cwd unless absolute, and "" otherwise.
+func localDir(arg, cwd string) string {
+	dir := arg
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(cwd, dir)
+	}
+	if fi, err := os.Stat(dir); err != nil || !fi.IsDir() {
+		return ""
+	}
+	return dir
+}
+
+// isLocalPath reports whether arg is a relative or absolute file system
+// path rather than an import path.
+func isLocalPath(arg string) bool {
+	return arg == "." || arg == ".." || strings.HasPrefix(arg, "./") || strings.HasPrefix(arg, "../") || filepath.IsAbs(arg)
+}
+
+// splitSymbolArg splits a "pkg.Symbol" or "pkg.Type.Method" argument, as
+// go doc addresses symbols, into the package argument and the symbol key.
+// The symbol follows the last slash and consists of exported identifiers.
+// An argument that resolves as a whole, such as "gopkg.in/yaml.v3", is a
+// package, and so is one with a version. Of "a/b.T.M", "a/b.T" is taken as
+// the package if it resolves and "a/b" otherwise.
+func splitSymbolArg(arg, cwd string) (pkgArg, key string, ok bool) {
+	if strings.Contains(arg, "@") || *dirArgs || isPattern(arg) {
+		return "", "", false
+	}
+	slash := strings.LastIndex(arg, "/") + 1
+	elems := strings.Split(arg[slash:], ".")
+	n := 0 // trailing exported identifiers, at most two
+	for n < 2 && n < len(elems)-1 {
+		if name := elems[len(elems)-1-n]; !token.IsIdentifier(name) || !token.IsExported(name) {
+			break
+		}
+		n++
+	}
+	if n == 0 || resolves(arg, cwd) {
+		return "", "", false
+	}
+	split := func(n int) (string, string) {
+		return arg[:slash] + strings.Join(elems[:len(elems)-n], "."), strings.Join(elems[len(elems)-n:], ".")
+	}
+	if n == 2 {
+		if pkgArg, key := split(1); resolves(pkgArg, cwd) {
+			return pkgArg, key, true
+		}
+	}
+	pkgArg, key = split(n)
+	return pkgArg, key, true
+}
+
+// resolves reports whether resolveDir finds arg.
+func resolves(arg, cwd string) bool {
+	_, err := resolveDir(arg, cwd)
+	return err == nil
+}
+
+// splitVersion splits a "path@version" argument. version is empty when
+// arg carries no version.
+func splitVersion(arg string) (path, version string) {
+	path, version, _ = strings.Cut(arg, "@")
+	return path, version
+}
+
+// cachedVersionDir finds the directory of importPath at the given module
+// version in GOMODCACHE. The module is the longest prefix of importPath
+// that is cached at that version.
+func cachedVersionDir(importPath, version string) string {
+	for modPath := importPath; modPath != "." && modPath != "/"; modPath = path.Dir(modPath) {
+		dir := filepath.Join(goModCache(), escapeModulePath(modPath)+"@"+escapeModulePath(version))
+		if fi, err := os.Stat(dir); err == nil && fi.IsDir() {
+			return filepath.Join(dir, strings.TrimPrefix(importPath, modPath))
+		}
+	}
+	return ""
+}
+
+// downloadVersionDir downloads the module holding importPath at the given
+// version with `go mod download` and returns the package directory. The
+// module is the longest prefix of importPath that can be downloaded.
+func downloadVersionDir(importPath, version string) string {
+	for modPath := importPath; modPath != "." && modPath != "/"; modPath = path.Dir(modPath) {
+		if dir := downloadModule(modPath, version); dir != "" {
+			return filepath.Join(dir, strings.TrimPrefix(importPath, modPath))
+		}
+	}
+	return ""
+}
+
+// downloadModule runs `go mod download` for modPath@version and returns the
+// extracted module directory, or "" if the module could not be downloaded.
+func downloadModule(modPath, version string) string {
+	out, err := exec.Command("go", "mod", "download", "-json", modPath+"@"+version).Output()
+	if err != nil && len(out) == 0 {
+		return ""
+	}
+	var mod struct {
+		Dir   string
+		Error string
+	}
+	if err := json.Unmarshal(out, &mod); err != nil || mod.Error != "" {
+		return ""
+	}
+	return mod.Dir
+}
+
+// escapeModulePath applies the module cache's case encoding, which replaces
+// every upper-case letter with '!' followed by its lower-case form.
+func escapeModulePath(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if unicode.IsUpper(r) {
+			b.WriteByte('!')
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// unescapeModulePath reverses escapeModulePath.
+func unescapeModulePath(s string) string {
+	var b strings.Builder
+	upper := false
+	for _, r := range s {
+		if r == '!' {
+			upper = true
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"github/urie96/go-list-export/pkg/export"
+)
+
+// writeRST writes a title per package and a section per file, like
+// writeMarkdown. Signatures go into code-block directives and doc
+// comments become paragraphs between them; consecutive undocumented
+// symbols share one directive.
+func writeRST(w io.Writer, pkg *export.Package, header bool) {
+	title := fmt.Sprintf("package ``%s``", pkg.Path)
+	if pkg.Version != "" {
+		title += fmt.Sprintf(" (%s)", pkg.Version)
+	}
+	rule := strings.Repeat("=", utf8.RuneCountInString(title))
+	fmt.Fprintf(w, "%s\n%s\n%s\n\n", rule, title, rule)
+	if pkg.Module != "" {
+		fmt.Fprintf(w, "Module ``%s``, read from ``%s``.\n\n", pkg.Module, pkg.Dir)
+	}
+	if pkg.Doc != "" {
+		fmt.Fprintf(w, "%s\n\n", pkg.Doc)
+	}
+	for _, f := range pkg.Files {
+		if f.Name != "" { // not combined
+			writeRSTSection(w, f.Name+fileNotes(f))
+		}
+		inBlock := false
+		for _, sym := range f.Symbols {
+			if sym.Doc != "" {
+				if inBlock {
+					fmt.Fprintln(w)
+					inBlock = false
+				}
+				fmt.Fprintf(w, "%s\n\n", sym.Doc)
+			}
+			if !inBlock {
+				fmt.Fprint(w, ".. code-block:: go\n\n")
+				inBlock = true
+			}
+			fmt.Fprintln(w, rstIndent(sym.Signature+annotation(sym)))
+		}
+		if inBlock {
+			fmt.Fprintln(w)
+		}
+	}
+	if len(pkg.Examples) > 0 {
+		writeRSTSection(w, "Examples")
+		for _, ex := range pkg.Examples {
+			fmt.Fprintf(w, "- ``%s`` (%s)\n", ex.Name, exampleTarget(ex))
+		}
+		fmt.Fprintln(w)
+	}
+	if len(pkg.Generate) > 0 {
+		writeRSTSection(w, "go:generate")
+		for _, d := range pkg.Generate {
+			fmt.Fprintf(w, "- ``%s`` (%s:%d)\n", d.Command, d.File, d.Line)
+		}
+		fmt.Fprintln(w)
+	}
+}
+
+// writeRSTSection writes a section title underlined with dashes.
+func writeRSTSection(w io.Writer, title string) {
+	fmt.Fprintf(w, "%s\n%s\n\n", title, strings.Repeat("-", utf8.RuneCountInString(title)))
+}
+
+// rstIndent indents the non-empty lines of s as the content of a
+// directive.
+func rstIndent(s string) string {
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		if line != "" {
+			lines[i] = "   " + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+	"go/types"
+)
+
+// wellKnownInterfaces are the interfaces satisfies checks besides error
+// and those given on the command line.
+var wellKnownInterfaces = []string{
+	"fmt.Stringer",
+	"fmt.GoStringer",
+	"fmt.Formatter",
+	"io.Reader",
+	"io.Writer",
+	"io.Closer",
+	"io.Seeker",
+	"io.ReadWriter",
+	"io.ReadCloser",
+	"io.WriteCloser",
+	"io.ReaderAt",
+	"io.WriterAt",
+	"io.ReaderFrom",
+	"io.WriterTo",
+	"io.ByteReader",
+	"io.ByteWriter",
+	"io.RuneReader",
+	"io.StringWriter",
+	"encoding.TextMarshaler",
+	"encoding.TextUnmarshaler",
+	"encoding.BinaryMarshaler",
+	"encoding.BinaryUnmarshaler",
+	"encoding/json.Marshaler",
+	"encoding/json.Unmarshaler",
+	"encoding/gob.GobEncoder",
+	"encoding/gob.GobDecoder",
+	"sort.Interface",
+	"flag.Value",
+	"hash.Hash",
+	"context.Context",
+	"database/sql.Scanner",
+	"database/sql/driver.Valuer",
+	"net/http.Handler",
+}
+
+// satisfied is an interface found by the satisfies command. Pointer is set
+// when only a pointer to the type implements it.
+type satisfied struct {
+	Interface string `json:"interface"`
+	Pointer   bool   `json:"pointer,omitempty"`
+}
+
+// runSatisfies prints the well-known interfaces, and those given after the
+// type argument, that the type or a pointer to it implements.
+func runSatisfies(cwd string, args []string) {
+	if len(args) == 0 {
+		usageError("satisfies: usage: satisfies pkg.Type [pkg.Interface...]")
+	}
+	q := newTypesQuery(cwd)
+	tn, err := q.lookupType(args[0])
+	if err != nil {
+		fatal(err)
+	}
+	typ := tn.Type()
+
+	found := []satisfied{}
+	check := func(name string, iface *types.Interface) {
+		switch {
+		case types.Implements(typ, iface):
+			found = append(found, satisfied{Interface: name})
+		case !types.IsInterface(typ) && types.Implements(types.NewPointer(typ), iface):
+			found = append(found, satisfied{Interface: name, Pointer: true})
+		}
+	}
+	check("error", types.Universe.Lookup("error").Type().Underlying().(*types.Interface))
+	for _, arg := range append(wellKnownInterfaces, args[1:]...) {
+		itn, err := q.lookupType(arg)
+		if err != nil {
+			report(err)
+			continue
+		}
+		iface, ok := itn.Type().Underlying().(*types.Interface)
+		if !ok {
+			report(fmt.Errorf("%s is not an interface", arg))
+			continue
+		}
+		check(arg, iface)
+	}
+
+	if *outputFormat == "json" {
+		encodeJSON(found)
+		return
+	}
+	for _, s := range found {
+		if s.Pointer {
+			fmt.Printf("%s (pointer receiver)\n", s.Interface)
+		} else {
+			fmt.Println(s.Interface)
+		}
+	}
+}
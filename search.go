@@ -0,0 +1,200 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github/urie96/go-list-export/pkg/export"
+)
+
+// cachedModule is a module version extracted in GOMODCACHE.
+type cachedModule struct {
+	Path    string
+	Version string
+	Dir     string
+}
+
+// cachedModules returns the modules in GOMODCACHE sorted by module path:
+// every cached version if all is set, and otherwise the preferred one.
+func cachedModules(all bool) []cachedModule {
+	root := goModCache()
+	versions := map[string][]string{}
+	filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+		if err != nil || !d.IsDir() {
+			return nil
+		}
+		if path == filepath.Join(root, "cache") {
+			return filepath.SkipDir
+		}
+		name := d.Name()
+		if i := strings.Index(name, "@"); i >= 0 {
+			rel, err := filepath.Rel(root, filepath.Join(filepath.Dir(path), name[:i]))
+			if err == nil {
+				modPath := filepath.ToSlash(rel)
+				versions[modPath] = append(versions[modPath], name[i+1:])
+			}
+			return filepath.SkipDir
+		}
+		return nil
+	})
+	mods := []cachedModule{}
+	for modPath, vs := range versions {
+		sortVersionsPreferred(vs)
+		if !all {
+			vs = vs[:1]
+		}
+		for _, v := range vs {
+			mods = append(mods, cachedModule{
+				Path:    unescapeModulePath(modPath),
+				Version: unescapeModulePath(v),
+				Dir:     filepath.Join(root, filepath.FromSlash(modPath)+"@"+v),
+			})
+		}
+	}
+	sort.SliceStable(mods, func(i, j int) bool { return mods[i].Path < mods[j].Path })
+	return mods
+}
+
+// cachedPackage is a package directory of a cached module.
+type cachedPackage struct {
+	Module *cachedModule
+	Rel    string // slash-separated directory within the module
+}
+
+func (p cachedPackage) importPath() string {
+	return joinImportPath(p.Module.Path, p.Rel)
+}
+
+// cachedPackages returns every package directory of the given modules.
+func cachedPackages(mods []cachedModule) []cachedPackage {
+	pkgs := []cachedPackage{}
+	for i := range mods {
+		rels, err := walkPackageDirs(mods[i].Dir, -1)
+		if err != nil {
+			report(err)
+		}
+		for _, rel := range rels {
+			pkgs = append(pkgs, cachedPackage{&mods[i], rel})
+		}
+	}
+	return pkgs
+}
+
+// searchHit is an exported symbol found by a search.
+type searchHit struct {
+	Path    string         `json:"path"`
+	Version string         `json:"version,omitempty"`
+	File    string         `json:"file"`
+	Symbol  *export.Symbol `json:"symbol"`
+	Score   int            `json:"score,omitempty"` // with --fuzzy
+}
+
+// runSearch prints every exported symbol of the preferred cached version
+// of each GOMODCACHE module whose name is one of args. Methods match by
+// their method name or by Type.Method. With --index the symbols are
+// looked up in the index database instead, covering every version it
+// holds. --fuzzy ranks approximate matches instead; see runFuzzySearch.
+func runSearch(cwd string, args []string) {
+	if len(args) == 0 {
+		usageError("search: no symbol names given")
+	}
+	if *fuzzy {
+		runFuzzySearch(args)
+		return
+	}
+	hits := []searchHit{}
+	if *useIndex {
+		found, err := searchIndex(args)
+		if err != nil {
+			fatal(err)
+		}
+		for _, hit := range found {
+			hits = printHit(hits, hit)
+		}
+	} else {
+		names := map[string]bool{}
+		for _, arg := range args {
+			names[arg] = true
+		}
+		cfg := newConfig()
+		pkgs := cachedPackages(cachedModules(false))
+		results := make([][]searchHit, len(pkgs))
+		orderedParallel(len(pkgs), func(i int) {
+			results[i] = searchPackage(cfg, pkgs[i], args, names)
+		}, func(i int) {
+			for _, hit := range results[i] {
+				hits = printHit(hits, hit)
+			}
+			results[i] = nil
+		})
+	}
+	if *outputFormat == "json" {
+		encodeJSON(hits)
+	}
+}
+
+// printHit prints a search hit, or with --format json collects it into
+// hits to be printed as one array.
+func printHit(hits []searchHit, hit searchHit) []searchHit {
+	if *outputFormat == "json" {
+		return append(hits, hit)
+	}
+	fmt.Printf("// %s@%s (%s):\n", hit.Path, hit.Version, hit.File)
+	fmt.Println(hit.Symbol.Signature + annotation(hit.Symbol))
+	fmt.Println()
+	return hits
+}
+
+// searchPackage returns the symbols of pkg named by names. Directories
+// whose Go files do not mention any of the names are not parsed.
+func searchPackage(cfg *export.Config, p cachedPackage, args []string, names map[string]bool) []searchHit {
+	dir := filepath.Join(p.Module.Dir, filepath.FromSlash(p.Rel))
+	if !dirMentions(dir, args) {
+		return nil
+	}
+	pkg, _ := parseDir(cfg, dir) // cached modules with broken files are not worth reporting
+	if pkg == nil || pkg.Name == "" {
+		return nil
+	}
+	filterPackage(pkg)
+	hits := []searchHit{}
+	for _, f := range pkg.Files {
+		for _, sym := range f.Symbols {
+			if names[sym.Name] || names[sym.Key()] {
+				hits = append(hits, searchHit{Path: p.importPath(), Version: p.Module.Version, File: f.Name, Symbol: sym})
+			}
+		}
+	}
+	return hits
+}
+
+// dirMentions reports whether a non-test Go file in dir contains the name
+// (or, for Type.Method, the method name) of any of args.
+func dirMentions(dir string, args []string) bool {
+	list, err := os.ReadDir(dir)
+	if err != nil {
+		return false
+	}
+	for _, d := range list {
+		if d.IsDir() || !strings.HasSuffix(d.Name(), ".go") || strings.HasSuffix(d.Name(), "_test.go") {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, d.Name()))
+		if err != nil {
+			continue
+		}
+		for _, arg := range args {
+			if i := strings.LastIndex(arg, "."); i >= 0 {
+				arg = arg[i+1:]
+			}
+			if bytes.Contains(data, []byte(arg)) {
+				return true
+			}
+		}
+	}
+	return false
+}
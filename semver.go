@@ -0,0 +1,151 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// semver is a parsed semantic version of the form vMAJOR.MINOR.PATCH[-PRE],
+// as used by Go module versions. Build metadata such as "+incompatible" is
+// dropped since it does not take part in ordering.
+type semver struct {
+	major, minor, patch int
+	pre                 string
+}
+
+func parseSemver(v string) (semver, bool) {
+	var sv semver
+	if !strings.HasPrefix(v, "v") {
+		return sv, false
+	}
+	v = v[1:]
+	if i := strings.Index(v, "+"); i >= 0 {
+		v = v[:i]
+	}
+	if i := strings.Index(v, "-"); i >= 0 {
+		sv.pre = v[i+1:]
+		v = v[:i]
+	}
+	parts := strings.Split(v, ".")
+	if len(parts) != 3 {
+		return sv, false
+	}
+	nums := []*int{&sv.major, &sv.minor, &sv.patch}
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 {
+			return sv, false
+		}
+		*nums[i] = n
+	}
+	return sv, true
+}
+
+// compareSemver compares two versions following semver precedence rules.
+// Invalid versions sort before all valid ones.
+func compareSemver(a, b string) int {
+	va, oka := parseSemver(a)
+	vb, okb := parseSemver(b)
+	switch {
+	case !oka && !okb:
+		return strings.Compare(a, b)
+	case !oka:
+		return -1
+	case !okb:
+		return 1
+	}
+	for _, d := range []int{va.major - vb.major, va.minor - vb.minor, va.patch - vb.patch} {
+		if d != 0 {
+			if d < 0 {
+				return -1
+			}
+			return 1
+		}
+	}
+	return comparePrerelease(va.pre, vb.pre)
+}
+
+// comparePrerelease orders prerelease strings; a release (empty prerelease)
+// has higher precedence than any prerelease.
+func comparePrerelease(a, b string) int {
+	if a == b {
+		return 0
+	}
+	if a == "" {
+		return 1
+	}
+	if b == "" {
+		return -1
+	}
+	pa := strings.Split(a, ".")
+	pb := strings.Split(b, ".")
+	for i := 0; i < len(pa) && i < len(pb); i++ {
+		if pa[i] == pb[i] {
+			continue
+		}
+		na, erra := strconv.Atoi(pa[i])
+		nb, errb := strconv.Atoi(pb[i])
+		switch {
+		case erra == nil && errb == nil:
+			if na < nb {
+				return -1
+			}
+			return 1
+		case erra == nil: // numeric identifiers sort before alphanumeric ones
+			return -1
+		case errb == nil:
+			return 1
+		default:
+			return strings.Compare(pa[i], pb[i])
+		}
+	}
+	if len(pa) < len(pb) {
+		return -1
+	}
+	return 1
+}
+
+func isPrerelease(v string) bool {
+	sv, ok := parseSemver(v)
+	return ok && sv.pre != ""
+}
+
+// sortVersionsPreferred sorts versions so that the preferred one comes
+// first: releases before prereleases (pseudo-versions count as
+// prereleases), each group newest first.
+func sortVersionsPreferred(versions []string) {
+	sort.SliceStable(versions, func(i, j int) bool {
+		pi, pj := isPrerelease(versions[i]), isPrerelease(versions[j])
+		if pi != pj {
+			return !pi
+		}
+		return compareSemver(versions[i], versions[j]) > 0
+	})
+}
+
+// nextVersion returns the version following v for a release that bumps
+// the given component ("major", "minor" or "patch"). Following Go module
+// conventions, a major bump of a v0 version only increments the minor
+// version. It returns "" if v is not a valid version.
+func nextVersion(v, bump string) string {
+	sv, ok := parseSemver(v)
+	if !ok {
+		return ""
+	}
+	if bump == "major" && sv.major == 0 {
+		bump = "minor"
+	}
+	switch bump {
+	case "major":
+		return fmt.Sprintf("v%d.0.0", sv.major+1)
+	case "minor":
+		return fmt.Sprintf("v%d.%d.0", sv.major, sv.minor+1)
+	default:
+		if sv.pre != "" { // the release of a prerelease is the next patch
+			return fmt.Sprintf("v%d.%d.%d", sv.major, sv.minor, sv.patch)
+		}
+		return fmt.Sprintf("v%d.%d.%d", sv.major, sv.minor, sv.patch+1)
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCompareSemver(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"v1.2.3", "v1.2.3", 0},
+		{"v1.2.3", "v1.10.0", -1},
+		{"v2.0.0", "v1.99.99", 1},
+		{"v1.0.0-rc.1", "v1.0.0", -1},
+		{"v1.0.0-alpha", "v1.0.0-alpha.1", -1},
+		{"v1.0.0-alpha.1", "v1.0.0-alpha.beta", -1},
+		{"v1.0.0-beta.2", "v1.0.0-beta.11", -1},
+		{"v1.0.0-rc.1", "v1.0.0-beta", 1},
+		{"v2.0.0+incompatible", "v2.0.0", 0},
+		{"latest", "v0.0.1", -1},
+		{"v1.2", "v1.2.0", -1},
+	}
+	for _, tt := range tests {
+		if got := compareSemver(tt.a, tt.b); got != tt.want {
+			t.Errorf("compareSemver(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+		if got := compareSemver(tt.b, tt.a); got != -tt.want {
+			t.Errorf("compareSemver(%q, %q) = %d, want %d", tt.b, tt.a, got, -tt.want)
+		}
+	}
+}
+
+func TestSortVersionsPreferred(t *testing.T) {
+	versions := []string{"v1.0.0", "v1.1.0-rc.1", "v0.9.0", "v1.1.0-0.20240101000000-abcdef123456", "v1.0.1"}
+	want := []string{"v1.0.1", "v1.0.0", "v0.9.0", "v1.1.0-rc.1", "v1.1.0-0.20240101000000-abcdef123456"}
+	sortVersionsPreferred(versions)
+	if !reflect.DeepEqual(versions, want) {
+		t.Errorf("sortVersionsPreferred() = %v, want %v", versions, want)
+	}
+}
+
+func TestNextVersion(t *testing.T) {
+	tests := []struct {
+		v, bump, want string
+	}{
+		{"v1.2.3", "major", "v2.0.0"},
+		{"v1.2.3", "minor", "v1.3.0"},
+		{"v1.2.3", "patch", "v1.2.4"},
+		{"v0.4.1", "major", "v0.5.0"},
+		{"v0.4.1", "minor", "v0.5.0"},
+		{"v1.3.0-rc.1", "patch", "v1.3.0"},
+		{"devel", "patch", ""},
+	}
+	for _, tt := range tests {
+		if got := nextVersion(tt.v, tt.bump); got != tt.want {
+			t.Errorf("nextVersion(%q, %q) = %q, want %q", tt.v, tt.bump, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,229 @@
+package main
+
+import (
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github/urie96/go-list-export/pkg/export"
+)
+
+// runServe serves the exported API of packages over HTTP. The arguments,
+// with patterns expanded, are listed on the index page and searched by
+// /search; any other import path can be browsed at /pkg/<import path>.
+// Packages are parsed on every request, so pages follow source changes.
+func runServe(cwd string, args []string) {
+	s := &server{cwd: cwd, paths: expandArgs(args, cwd)}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", s.index)
+	mux.HandleFunc("/pkg/", s.pkg)
+	mux.HandleFunc("/search", s.search)
+	fmt.Fprintf(os.Stderr, "// serving on http://%s\n", *addr)
+	if err := http.ListenAndServe(*addr, mux); err != nil {
+		fatal(err)
+	}
+}
+
+// expandArgs returns the import paths of args, with every pattern replaced
+// by the packages it matches.
+func expandArgs(args []string, cwd string) []string {
+	paths := []string{}
+	for _, arg := range args {
+		if *allPackages {
+			arg = allPackagesPattern(arg)
+		}
+		if !isPattern(arg) {
+			paths = append(paths, arg)
+			continue
+		}
+		root := patternRoot(arg)
+		rootDir, err := resolveOrDownloadDir(root, cwd)
+		if err != nil {
+			report(err)
+			continue
+		}
+		rels, err := walkPackageDirs(rootDir, *maxDepth)
+		if err != nil {
+			report(err)
+		}
+		rels = dropExcluded(root, dropInternal(rels))
+		for _, rel := range rels {
+			paths = append(paths, joinImportPath(root, rel))
+		}
+	}
+	return paths
+}
+
+type server struct {
+	cwd   string
+	paths []string
+}
+
+// load parses one package for a page and prepares and arranges it as the
+// list command does: filtered, with values limited, grouped, sorted and
+// combined as the flags ask. Only the files changed since the package was
+// last loaded are parsed again.
+func (s *server) load(path string) (*export.Package, error) {
+	cfg := newConfig()
+	cfg.Incremental = true
+	pkg, err := loadPackage(cfg, path, s.cwd)
+	if pkg != nil {
+		preparePackage(pkg)
+		arrangePackage(pkg)
+	}
+	return pkg, err
+}
+
+func (s *server) index(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+	renderPage(w, "index", map[string]any{"Paths": s.paths})
+}
+
+func (s *server) pkg(w http.ResponseWriter, r *http.Request) {
+	path := strings.Trim(strings.TrimPrefix(r.URL.Path, "/pkg/"), "/")
+	if q := r.URL.Query().Get("path"); q != "" {
+		// Set by the index page's form, and by links to local directories,
+		// whose paths do not survive the cleaning of URL paths.
+		path = q
+	}
+	if path == "" {
+		http.Redirect(w, r, "/", http.StatusFound)
+		return
+	}
+	pkg, err := s.load(path)
+	if pkg == nil {
+		w.WriteHeader(http.StatusNotFound)
+		renderPage(w, "error", map[string]any{"Path": path, "Err": err})
+		return
+	}
+	pkg.Path = path
+	renderPage(w, "package", map[string]any{"Pkg": pkg, "Err": err, "Links": typeLinker(pkg)})
+}
+
+// search matches a case-insensitive substring against the names (or
+// Type.Method keys) of the symbols of the package given by the "pkg"
+// parameter, or of every package on the index page.
+func (s *server) search(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query().Get("q")
+	paths := s.paths
+	if p := r.URL.Query().Get("pkg"); p != "" {
+		paths = []string{p}
+	}
+	hits := []searchHit{}
+	if q != "" {
+		needle := strings.ToLower(q)
+		for _, path := range paths {
+			pkg, _ := s.load(path)
+			if pkg == nil {
+				continue
+			}
+			for _, sym := range pkg.Symbols() {
+				if strings.Contains(strings.ToLower(sym.Key()), needle) {
+					hits = append(hits, searchHit{Path: path, Symbol: sym})
+				}
+			}
+		}
+	}
+	sort.SliceStable(hits, func(i, j int) bool { return hits[i].Symbol.Key() < hits[j].Symbol.Key() })
+	renderPage(w, "search", map[string]any{"Query": q, "Pkg": r.URL.Query().Get("pkg"), "Hits": hits})
+}
+
+// pkgURL returns the URL of the page of a package argument.
+func pkgURL(path string) string {
+	if isLocalPath(path) {
+		return "/pkg/?path=" + url.QueryEscape(path)
+	}
+	return "/pkg/" + path
+}
+
+// identRe matches the identifiers in a signature that may name a type.
+var identRe = regexp.MustCompile(`\b[A-Z]\w*\b`)
+
+// typeLinker returns a function that renders a signature as HTML, linking
+// every type declared in pkg to its declaration on the page.
+func typeLinker(pkg *export.Package) func(sig string) template.HTML {
+	types := map[string]bool{}
+	for _, sym := range pkg.Symbols() {
+		if sym.Kind == export.KindType {
+			types[sym.Name] = true
+		}
+	}
+	return func(sig string) template.HTML {
+		var b strings.Builder
+		last := 0
+		for _, m := range identRe.FindAllStringIndex(sig, -1) {
+			name := sig[m[0]:m[1]]
+			if !types[name] || m[0] > 0 && sig[m[0]-1] == '.' {
+				continue
+			}
+			b.WriteString(template.HTMLEscapeString(sig[last:m[0]]))
+			fmt.Fprintf(&b, `<a href="#%s">%s</a>`, name, name)
+			last = m[1]
+		}
+		b.WriteString(template.HTMLEscapeString(sig[last:]))
+		return template.HTML(b.String())
+	}
+}
+
+func renderPage(w http.ResponseWriter, name string, data map[string]any) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := pages.ExecuteTemplate(w, name, data); err != nil {
+		// Not report, which sets the exit code: handlers run
+		// concurrently, and a failed page does not fail the server.
+		fmt.Fprintf(os.Stderr, "go-list-export: %v\n", err)
+	}
+}
+
+var pages = template.Must(template.New("").Funcs(template.FuncMap{
+	"annotation": annotation,
+	"pkgURL":     pkgURL,
+}).Parse(`
+{{define "head"}}<!DOCTYPE html>
+<html><head><meta charset="utf-8"><title>{{.}}</title>
+<style>
+body { font-family: sans-serif; max-width: 60em; margin: 2em auto; }
+pre { background: #f6f8fa; padding: .5em; margin: .3em 0 1em; white-space: pre-wrap; }
+.doc { white-space: pre-wrap; margin: 0; }
+.err { color: #b00; }
+</style></head><body>
+<p><a href="/">index</a></p>
+{{end}}
+
+{{define "index"}}{{template "head" "go-list-export"}}
+<h1>Packages</h1>
+<form action="/pkg/"><input name="path" size="50" placeholder="import path"> <button>Open</button></form>
+<form action="/search"><input name="q" size="50" placeholder="symbol name"> <button>Search</button></form>
+<ul>{{range .Paths}}<li><a href="{{pkgURL .}}">{{.}}</a></li>{{end}}</ul>
+</body></html>{{end}}
+
+{{define "package"}}{{template "head" .Pkg.Path}}
+<h1>package {{.Pkg.Name}}</h1>
+<p><code>{{.Pkg.Path}}</code>{{with .Pkg.Version}} {{.}}{{end}}</p>
+<form action="/search"><input type="hidden" name="pkg" value="{{.Pkg.Path}}"><input name="q" size="50" placeholder="symbol name"> <button>Search</button></form>
+{{with .Err}}<p class="err">{{.}}</p>{{end}}
+{{$links := .Links}}
+{{range .Pkg.Files}}<h2>{{.Name}}</h2>
+{{range .Symbols}}<div id="{{.Key}}">{{with .Doc}}<p class="doc">{{.}}</p>{{end}}<pre>{{call $links .Signature}}{{annotation .}}</pre></div>
+{{end}}{{end}}
+</body></html>{{end}}
+
+{{define "search"}}{{template "head" "search"}}
+<h1>Search</h1>
+<form action="/search">{{with .Pkg}}<input type="hidden" name="pkg" value="{{.}}">{{end}}<input name="q" size="50" value="{{.Query}}"> <button>Search</button></form>
+{{if .Query}}<p>{{len .Hits}} matches{{with .Pkg}} in {{.}}{{end}}</p>{{end}}
+<ul>{{range .Hits}}<li><a href="{{pkgURL .Path}}#{{.Symbol.Key}}">{{.Symbol.Key}}</a> <code>{{.Path}}</code></li>{{end}}</ul>
+</body></html>{{end}}
+
+{{define "error"}}{{template "head" .Path}}
+<h1>{{.Path}}</h1>
+<p class="err">{{.Err}}</p>
+</body></html>{{end}}
+`))
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+
+	"github/urie96/go-list-export/pkg/export"
+)
+
+// writeSexp writes pkg as one Lisp form that Emacs can read with `read`.
+// Structs become property lists whose keywords are the json field names,
+// with omitempty fields left out as in the JSON model; slices become
+// lists, strings strings and booleans t or nil. Each file and symbol
+// starts a line of its own.
+func writeSexp(w io.Writer, pkg *export.Package, header bool) {
+	fmt.Fprintln(w, sexpValue(reflect.ValueOf(pkg), ""))
+}
+
+// sexpValue renders v. Lists of structs put every element after the
+// first on a new line, indented past indent.
+func sexpValue(v reflect.Value, indent string) string {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return "nil"
+		}
+		v = v.Elem()
+	}
+	switch v.Kind() {
+	case reflect.Struct:
+		t := v.Type()
+		items := []string{}
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			name, opts, _ := strings.Cut(field.Tag.Get("json"), ",")
+			if name == "-" {
+				continue
+			}
+			if name == "" {
+				name = field.Name
+			}
+			fv := v.Field(i)
+			if strings.Contains(opts, "omitempty") && isEmptyValue(fv) {
+				continue
+			}
+			items = append(items, ":"+name+" "+sexpValue(fv, indent+" "))
+		}
+		return "(" + strings.Join(items, " ") + ")"
+	case reflect.Slice, reflect.Array:
+		if v.Len() == 0 {
+			return "nil"
+		}
+		elem := v.Type().Elem()
+		for elem.Kind() == reflect.Pointer {
+			elem = elem.Elem()
+		}
+		sep := " "
+		if elem.Kind() == reflect.Struct {
+			indent += " "
+			sep = "\n" + indent
+		}
+		items := make([]string, v.Len())
+		for i := range items {
+			items[i] = sexpValue(v.Index(i), indent)
+		}
+		return "(" + strings.Join(items, sep) + ")"
+	case reflect.String:
+		return sexpString(v.String())
+	case reflect.Bool:
+		if v.Bool() {
+			return "t"
+		}
+		return "nil"
+	default:
+		return fmt.Sprint(v.Interface())
+	}
+}
+
+// sexpString quotes s as an Emacs Lisp string. Newlines and tabs are
+// escaped too, to keep every symbol on one line.
+func sexpString(s string) string {
+	return `"` + sexpEscaper.Replace(s) + `"`
+}
+
+var sexpEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\t", `\t`)
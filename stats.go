@@ -0,0 +1,110 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github/urie96/go-list-export/pkg/export"
+)
+
+// apiStats is a line of the --stats report: the size of a package's API
+// by kind of symbol.
+type apiStats struct {
+	Path       string `json:"path,omitempty"`
+	Version    string `json:"version,omitempty"`
+	Funcs      int    `json:"funcs"`
+	Methods    int    `json:"methods"`
+	Types      int    `json:"types"`
+	Vars       int    `json:"vars"`
+	Consts     int    `json:"consts"`
+	Generic    int    `json:"generic"`
+	Deprecated int    `json:"deprecated"`
+	Files      int    `json:"files"`
+}
+
+// add adds the counts of st to s.
+func (s *apiStats) add(st apiStats) {
+	s.Funcs += st.Funcs
+	s.Methods += st.Methods
+	s.Types += st.Types
+	s.Vars += st.Vars
+	s.Consts += st.Consts
+	s.Generic += st.Generic
+	s.Deprecated += st.Deprecated
+	s.Files += st.Files
+}
+
+// packageAPIStats collects the --stats report as packages are listed.
+var packageAPIStats []apiStats
+
+// addAPIStats records the counts of pkg as it would have been printed.
+func addAPIStats(pkg *export.Package) {
+	st := apiStats{Path: pkg.Path, Version: pkg.Version, Files: len(pkg.Files)}
+	for _, sym := range pkg.Symbols() {
+		switch sym.Kind {
+		case export.KindFunc:
+			st.Funcs++
+		case export.KindMethod:
+			st.Methods++
+		case export.KindType:
+			st.Types++
+		case export.KindVar:
+			st.Vars++
+		case export.KindConst:
+			st.Consts++
+		}
+		if isGeneric(sym) {
+			st.Generic++
+		}
+		if sym.Deprecated != "" {
+			st.Deprecated++
+		}
+	}
+	packageAPIStats = append(packageAPIStats, st)
+}
+
+// isGeneric reports whether sym has type parameters: a generic type or
+// function, or a method of a generic type.
+func isGeneric(sym *export.Symbol) bool {
+	switch sym.Kind {
+	case export.KindMethod:
+		return strings.Contains(sym.Receiver, "[")
+	case export.KindFunc:
+		return strings.HasPrefix(sym.Signature, "func "+sym.Name+"[")
+	case export.KindType:
+		return strings.HasPrefix(sym.Signature, "type "+sym.Name+"[")
+	}
+	return false
+}
+
+// printAPIStats prints the --stats report, a line per package followed by
+// the totals, as a table or with --format json as an object.
+func printAPIStats() {
+	var total apiStats
+	for _, st := range packageAPIStats {
+		total.add(st)
+	}
+	if *outputFormat == "json" {
+		encodeJSON(struct {
+			Packages []apiStats `json:"packages"`
+			Total    apiStats   `json:"total"`
+		}{packageAPIStats, total})
+		return
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "FUNCS\tMETHODS\tTYPES\tVARS\tCONSTS\tGENERIC\tDEPRECATED\tFILES\t PACKAGE")
+	row := func(st apiStats, path string) {
+		fmt.Fprintf(w, "%d\t%d\t%d\t%d\t%d\t%d\t%d\t%d\t %s\n", st.Funcs, st.Methods, st.Types, st.Vars, st.Consts, st.Generic, st.Deprecated, st.Files, path)
+	}
+	for _, st := range packageAPIStats {
+		path := st.Path
+		if st.Version != "" {
+			path += "@" + st.Version
+		}
+		row(st, path)
+	}
+	row(total, "total")
+	w.Flush()
+}
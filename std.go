@@ -0,0 +1,72 @@
+package main
+
+import (
+	"bufio"
+	"go/build"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// goRoot returns the Go installation used for standard library packages:
+// go/build's GOROOT (which honors $GOROOT), or else the one reported by
+// the go command in PATH.
+var goRoot = sync.OnceValue(func() string {
+	if build.Default.GOROOT != "" {
+		return build.Default.GOROOT
+	}
+	out, err := exec.Command("go", "env", "GOROOT").Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+})
+
+// goRootVersion returns the Go release of goRoot, e.g. "go1.22.3", which
+// stands in for the version of standard library packages.
+var goRootVersion = sync.OnceValue(func() string {
+	if f, err := os.Open(filepath.Join(goRoot(), "VERSION")); err == nil {
+		defer f.Close()
+		sc := bufio.NewScanner(f)
+		if sc.Scan() && strings.HasPrefix(sc.Text(), "go") {
+			return sc.Text()
+		}
+	}
+	return runtime.Version()
+})
+
+// isStdImportPath reports whether importPath names a package of the
+// standard library. Like the go command, it relies on the first path
+// element of non-standard packages containing a dot.
+func isStdImportPath(importPath string) bool {
+	if importPath == "" || strings.HasPrefix(importPath, ".") || filepath.IsAbs(importPath) {
+		return false
+	}
+	first, _, _ := strings.Cut(importPath, "/")
+	return !strings.Contains(first, ".")
+}
+
+// stdPackageDir returns the GOROOT source directory of a standard library
+// package, or "" if there is no such package.
+func stdPackageDir(importPath string) string {
+	if goRoot() == "" || !isStdImportPath(importPath) {
+		return ""
+	}
+	dir := filepath.Join(goRoot(), "src", filepath.FromSlash(importPath))
+	if fi, err := os.Stat(dir); err != nil || !fi.IsDir() {
+		return ""
+	}
+	return dir
+}
+
+// isGoRootDir reports whether dir lies in GOROOT's source tree.
+func isGoRootDir(dir string) bool {
+	if goRoot() == "" {
+		return false
+	}
+	rel, err := filepath.Rel(filepath.Join(goRoot(), "src"), dir)
+	return err == nil && !strings.HasPrefix(rel, "..")
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"testing/fstest"
+
+	"github/urie96/go-list-export/pkg/export"
+)
+
+// stdinArg is the argument, also implied by --stdin, that reads the
+// package from stdin.
+const stdinArg = "-"
+
+// loadStdin parses the package read from stdin, which is either a single
+// Go file or a tar stream of the package's files. In a tar stream the
+// package is the shallowest directory holding Go files. The package has
+// no directory and takes its path from its name.
+func loadStdin(cfg *export.Config) (*export.Package, error) {
+	data, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		return nil, err
+	}
+	fsys, dir := fstest.MapFS{"stdin.go": {Data: data}}, "."
+	if isTar(data) {
+		if fsys, dir, err = tarFS(data); err != nil {
+			return nil, err
+		}
+	}
+	pkg, err := cfg.ParseFS(fsys, dir)
+	if pkg == nil {
+		return nil, err
+	}
+	if pkg.Name == "" && err == nil {
+		return nil, &notFoundError{arg: stdinArg, reason: "no Go package on stdin"}
+	}
+	pkg.Path, pkg.Dir = pkg.Name, ""
+	return pkg, err
+}
+
+// isTar reports whether data starts with a POSIX or GNU tar header.
+func isTar(data []byte) bool {
+	return len(data) >= 262 && bytes.HasPrefix(data[257:], []byte("ustar"))
+}
+
+// tarFS reads the regular files of a tar stream into memory and returns
+// them with the directory of the package.
+func tarFS(data []byte) (fstest.MapFS, string, error) {
+	fsys, err := tarFiles(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", err
+	}
+	dir := ""
+	for name := range fsys {
+		if strings.HasSuffix(name, ".go") {
+			if d := path.Dir(name); dir == "" || depth(d) < depth(dir) || depth(d) == depth(dir) && d < dir {
+				dir = d
+			}
+		}
+	}
+	if dir == "" {
+		return nil, "", errors.New("no Go files in the tar stream on stdin")
+	}
+	return fsys, dir, nil
+}
+
+// tarFiles reads the regular files of a tar stream into memory.
+func tarFiles(r io.Reader) (fstest.MapFS, error) {
+	fsys := fstest.MapFS{}
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return fsys, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		content, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, err
+		}
+		fsys[path.Clean(strings.TrimPrefix(hdr.Name, "/"))] = &fstest.MapFile{Data: content, Mode: 0o644}
+	}
+}
+
+// depth returns the number of elements of the slash-separated dir.
+func depth(dir string) int {
+	if dir == "." {
+		return 0
+	}
+	return strings.Count(dir, "/") + 1
+}
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github/urie96/go-list-export/pkg/export"
+)
+
+// JSON-RPC 2.0 error codes.
+const (
+	rpcParseError     = -32700
+	rpcMethodNotFound = -32601
+	rpcInvalidParams  = -32602
+)
+
+type rpcRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+// rpcResponse is the response to a successful call. JSON-RPC requires its
+// result, even when empty, and forbids an error member alongside it.
+type rpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  any             `json:"result"`
+}
+
+// rpcErrorResponse is the response to a failed call, without a result.
+type rpcErrorResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Error   *rpcError       `json:"error"`
+}
+
+// rpcError is a JSON-RPC error. Failures to list a package carry the exit
+// code the list command would have exited with.
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// listParams are the parameters of the "list" method. Format defaults to
+// json, whose result is the package object; the result of the other
+// formats is their output as a string.
+type listParams struct {
+	Path   string `json:"path"`
+	Format string `json:"format,omitempty"`
+}
+
+// parsed is a package parsed by the daemon, kept until the Go files of
+// its directory change.
+type parsed struct {
+	pkg  *export.Package
+	err  error
+	snap map[string]fileState
+}
+
+// stdioServer answers JSON-RPC requests read from stdin on stdout.
+type stdioServer struct {
+	cfg   *export.Config
+	cwd   string
+	cache map[string]*parsed
+
+	// framed is set once a request came with a Content-Length header,
+	// as in the Language Server Protocol; responses then use it too.
+	// Otherwise messages are separated by newlines.
+	framed bool
+}
+
+// runStdio serves "list" requests over stdin and stdout until stdin is
+// closed or a "shutdown" request is answered. Listing applies the global
+// flags as the list command does; parses are cached, so repeated
+// requests for a package only parse it again after its files change.
+func runStdio(cfg *export.Config, cwd string) {
+	s := &stdioServer{cfg: cfg, cwd: cwd, cache: map[string]*parsed{}}
+	r := bufio.NewReader(os.Stdin)
+	for {
+		msg, err := s.read(r)
+		if err == io.EOF {
+			return
+		}
+		if err != nil {
+			fatal(err)
+		}
+		if len(bytes.TrimSpace(msg)) == 0 {
+			continue
+		}
+		var req rpcRequest
+		if err := json.Unmarshal(msg, &req); err != nil {
+			s.write(json.RawMessage("null"), nil, &rpcError{rpcParseError, err.Error()})
+			continue
+		}
+		result, rerr := s.handle(req)
+		if req.ID == nil {
+			continue // a notification
+		}
+		s.write(req.ID, result, rerr)
+		if req.Method == "shutdown" {
+			return
+		}
+	}
+}
+
+func (s *stdioServer) handle(req rpcRequest) (any, *rpcError) {
+	switch req.Method {
+	case "shutdown":
+		return true, nil
+	case "list":
+	default:
+		return nil, &rpcError{rpcMethodNotFound, "unknown method " + req.Method}
+	}
+	var p listParams
+	if err := json.Unmarshal(req.Params, &p); err != nil || p.Path == "" {
+		return nil, &rpcError{rpcInvalidParams, `list needs {"path": "..."}`}
+	}
+	if p.Format == "" {
+		p.Format = "json"
+	}
+	if formatters[p.Format] == nil || p.Format == "template" && userTemplate == nil {
+		return nil, &rpcError{rpcInvalidParams, fmt.Sprintf("unknown format '%s'", p.Format)}
+	}
+	if isPattern(p.Path) {
+		return nil, &rpcError{rpcInvalidParams, "list takes a single package, not a pattern"}
+	}
+	pkg, err := s.load(p.Path)
+	if pkg == nil {
+		return nil, &rpcError{codeFor(err), err.Error()}
+	}
+
+	format := *outputFormat
+	*outputFormat = p.Format
+	var buf bytes.Buffer
+	stdout = &buf
+	printPackage(pkg, false)
+	stdout = os.Stdout
+	*outputFormat = format
+	if p.Format == "json" {
+		return json.RawMessage(buf.Bytes()), nil
+	}
+	return buf.String(), nil
+}
+
+// load returns a copy of the package of path, parsing it unless the
+// cached parse is still current.
+func (s *stdioServer) load(path string) (*export.Package, error) {
+	if c := s.cache[path]; c != nil && sameSnapshot(c.snap, snapshot([]watchTarget{{dir: c.pkg.Dir}})) {
+		return c.pkg.Clone(), c.err
+	}
+	pkg, err := loadPackage(s.cfg, path, s.cwd)
+	if pkg == nil {
+		delete(s.cache, path)
+		return nil, err
+	}
+	s.cache[path] = &parsed{pkg: pkg, err: err, snap: snapshot([]watchTarget{{dir: pkg.Dir}})}
+	return pkg.Clone(), err
+}
+
+// read returns the next message: a line, or the body following a
+// Content-Length header.
+func (s *stdioServer) read(r *bufio.Reader) ([]byte, error) {
+	line, err := r.ReadBytes('\n')
+	if err != nil && (err != io.EOF || len(line) == 0) {
+		return nil, err
+	}
+	value, ok := strings.CutPrefix(string(line), "Content-Length:")
+	if !ok {
+		return line, nil
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil {
+		return nil, fmt.Errorf("bad Content-Length header: %q", line)
+	}
+	for { // the remaining headers, up to an empty line
+		h, err := r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		if strings.TrimSpace(h) == "" {
+			break
+		}
+	}
+	s.framed = true
+	body := make([]byte, n)
+	_, err = io.ReadFull(r, body)
+	return body, err
+}
+
+func (s *stdioServer) write(id json.RawMessage, result any, rerr *rpcError) {
+	var resp any = rpcResponse{JSONRPC: "2.0", ID: id, Result: result}
+	if rerr != nil {
+		resp = rpcErrorResponse{JSONRPC: "2.0", ID: id, Error: rerr}
+	}
+	data, err := json.Marshal(resp)
+	if err != nil {
+		data, _ = json.Marshal(rpcErrorResponse{JSONRPC: "2.0", ID: id, Error: &rpcError{exitError, err.Error()}})
+	}
+	if s.framed {
+		fmt.Fprintf(os.Stdout, "Content-Length: %d\r\n\r\n%s", len(data), data)
+	} else {
+		fmt.Fprintf(os.Stdout, "%s\n", data)
+	}
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github/urie96/go-list-export/pkg/export"
+)
+
+// ctagsKinds maps symbol kinds to the single-letter kinds of Universal
+// Ctags' Go parser.
+var ctagsKinds = map[export.Kind]string{
+	export.KindFunc:   "f",
+	export.KindMethod: "f",
+	export.KindType:   "t",
+	export.KindVar:    "v",
+	export.KindConst:  "c",
+}
+
+// ctagsHeaderDone records that the pseudo-tags starting a tags file have
+// been written, since a single file covers every listed package.
+var ctagsHeaderDone bool
+
+// writeCtags writes a line per symbol in the extended ctags format used by
+// vim, addressing declarations by line number. Tags are in listing order
+// rather than sorted by name, as the header tells readers.
+func writeCtags(w io.Writer, pkg *export.Package, header bool) {
+	if !ctagsHeaderDone {
+		fmt.Fprintln(w, "!_TAG_FILE_FORMAT\t2\t/extended format/")
+		fmt.Fprintln(w, "!_TAG_FILE_SORTED\t0\t/0=unsorted, 1=sorted, 2=foldcase/")
+		ctagsHeaderDone = true
+	}
+	for _, f := range pkg.Files {
+		file := filepath.Join(pkg.Dir, f.Name)
+		for _, sym := range f.Symbols {
+			if sym.Pos == nil {
+				continue
+			}
+			fmt.Fprintf(w, "%s\t%s\t%d;\"\t%s", sym.Name, file, sym.Pos.Line, ctagsKinds[sym.Kind])
+			if sym.Kind == export.KindMethod {
+				fmt.Fprintf(w, "\tntype:%s", receiverType(sym))
+			}
+			fmt.Fprintf(w, "\tpackage:%s\n", pkg.Path)
+		}
+	}
+}
+
+// writeEtags writes a section of an Emacs TAGS file per source file. Each
+// tag carries the text of its source line, which Emacs searches for near
+// the recorded line and offset; when the source cannot be read, as for
+// packages read from module zips, the signature stands in for it.
+func writeEtags(w io.Writer, pkg *export.Package, header bool) {
+	for _, f := range pkg.Files {
+		file := filepath.Join(pkg.Dir, f.Name)
+		src, _ := os.ReadFile(file)
+		var b bytes.Buffer
+		for _, sym := range f.Symbols {
+			if sym.Pos == nil {
+				continue
+			}
+			text := sourceLine(src, sym.Pos)
+			if text == "" {
+				text, _, _ = strings.Cut(sym.Signature, "\n")
+			}
+			fmt.Fprintf(&b, "%s\x7f%s\x01%d,%d\n", text, sym.Name, sym.Pos.Line, sym.Pos.Offset-sym.Pos.Column+1)
+		}
+		fmt.Fprintf(w, "\f\n%s,%d\n", file, b.Len())
+		b.WriteTo(w)
+	}
+}
+
+// sourceLine returns the text of the line holding pos up to the end of the
+// declared name, or "" if src does not cover pos.
+func sourceLine(src []byte, pos *export.Position) string {
+	start := pos.Offset - pos.Column + 1
+	if src == nil || start < 0 || pos.Offset > len(src) {
+		return ""
+	}
+	line := src[start:]
+	if i := bytes.IndexByte(line, '\n'); i >= 0 {
+		line = line[:i]
+	}
+	end := pos.Column - 1
+	for end < len(line) && (line[end] == '_' || isIdentByte(line[end])) {
+		end++
+	}
+	return string(line[:end])
+}
+
+func isIdentByte(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || c >= 0x80
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/template"
+
+	"github/urie96/go-list-export/pkg/export"
+)
+
+// userTemplate is the template given with --template or -t.
+var userTemplate *template.Template
+
+// templateFuncs are available to user templates besides the built-in
+// functions of text/template.
+var templateFuncs = template.FuncMap{
+	"annotation": annotation,
+	"join":       strings.Join,
+	"lines":      func(s string) []string { return strings.Split(s, "\n") },
+	"json": func(v any) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+}
+
+// templateData is what user templates are executed with: the package,
+// whose fields are those of the JSON output, and whether it should be
+// announced with a header.
+type templateData struct {
+	*export.Package
+	Header bool
+}
+
+// loadTemplate parses the template of --template or -t, if any, and
+// selects the "template" format for it.
+func loadTemplate() error {
+	text := *templateText
+	name := "-t"
+	if *templateFile != "" {
+		if text != "" {
+			return fmt.Errorf("--template and -t are mutually exclusive")
+		}
+		b, err := os.ReadFile(*templateFile)
+		if err != nil {
+			return err
+		}
+		text, name = string(b), *templateFile
+	}
+	if text == "" {
+		return nil
+	}
+	t, err := template.New(name).Funcs(templateFuncs).Parse(text)
+	if err != nil {
+		return err
+	}
+	userTemplate = t
+	*outputFormat = "template"
+	return nil
+}
+
+func writeTemplate(w io.Writer, pkg *export.Package, header bool) {
+	if err := userTemplate.Execute(w, templateData{pkg, header}); err != nil {
+		fatal(err)
+	}
+}
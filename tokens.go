@@ -0,0 +1,196 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github/urie96/go-list-export/pkg/export"
+)
+
+// estimateTokens approximates the number of LLM tokens in text. The usual
+// rule of thumb of four bytes per token holds well enough for Go source.
+func estimateTokens(text []byte) int {
+	return (len(text) + 3) / 4
+}
+
+// packageTokens estimates the tokens of pkg in the selected output format.
+// The state the ctags and tree formats keep between packages is restored
+// afterwards, so that measuring a package does not change how it is
+// printed.
+func packageTokens(pkg *export.Package, header bool) int {
+	headerDone, stack := ctagsHeaderDone, treeStack
+	defer func() { ctagsHeaderDone, treeStack = headerDone, stack }()
+	var buf bytes.Buffer
+	formatters[*outputFormat](&buf, pkg, header)
+	return estimateTokens(buf.Bytes())
+}
+
+// dropRank orders symbols by how readily --max-tokens drops them, lowest
+// first: deprecated symbols, then constants, variables, methods, and
+// finally the functions and types that make up the core of an API.
+func dropRank(sym *export.Symbol) int {
+	if sym.Deprecated != "" {
+		return 0
+	}
+	switch sym.Kind {
+	case export.KindConst:
+		return 1
+	case export.KindVar:
+		return 2
+	case export.KindMethod:
+		return 3
+	}
+	return 4
+}
+
+// fitTokens compacts pkg until it fits in --max-tokens tokens, one step
+// at a time: drop doc comments, drop var and const values, drop parameter
+// names, collapse struct and interface bodies, and finally drop symbols in
+// dropRank order, last declared first. The budget applies to each package
+// separately. Steps that need the source parsed again are skipped for
+// packages that were not read from a directory.
+func fitTokens(pkg *export.Package, header bool) *export.Package {
+	budget := *maxTokens
+	if packageTokens(pkg, header) <= budget {
+		return pkg
+	}
+	steps := []string{}
+	fits := func(step string) bool {
+		steps = append(steps, step)
+		return packageTokens(pkg, header) <= budget
+	}
+	defer func() {
+		fmt.Fprintf(os.Stderr, "// %s: compacted to fit %d tokens: %s\n", pkg.Path, budget, strings.Join(steps, ", "))
+	}()
+
+	dropDocs(pkg)
+	if fits("dropped doc comments") {
+		return pkg
+	}
+	dropValues(pkg)
+	if fits("dropped values") {
+		return pkg
+	}
+	// The reparsed package keeps only the symbols listed so far, so that
+	// a pkg.Symbol argument still lists the one symbol.
+	listed := map[string]bool{}
+	for _, sym := range pkg.Symbols() {
+		listed[sym.Key()] = true
+	}
+	reparse := func(step string, set func(cfg *export.Config)) bool {
+		cfg := newConfig()
+		cfg.Docs = false
+		cfg.OmitParamNames = true
+		set(cfg)
+		p, _ := cfg.Reparse(pkg)
+		if p == nil || p.Name == "" {
+			return false
+		}
+		p.Path, p.Module, p.Version = pkg.Path, pkg.Module, pkg.Version
+		p.Filter(func(sym *export.Symbol) bool { return listed[sym.Key()] })
+		preparePackage(p)
+		arrangePackage(p)
+		dropValues(p)
+		pkg = p
+		return fits(step)
+	}
+	if _, err := os.Stat(pkg.Dir); err == nil {
+		if reparse("dropped parameter names", func(*export.Config) {}) {
+			return pkg
+		}
+		if reparse("collapsed struct and interface bodies", func(cfg *export.Config) { cfg.Compact = true }) {
+			return pkg
+		}
+	}
+
+	// Drop symbols in dropRank order, later declarations first within a
+	// rank, searching for the fewest drops that fit.
+	syms := pkg.Symbols()
+	for i, j := 0, len(syms)-1; i < j; i, j = i+1, j-1 {
+		syms[i], syms[j] = syms[j], syms[i]
+	}
+	sort.SliceStable(syms, func(i, j int) bool { return dropRank(syms[i]) < dropRank(syms[j]) })
+	without := func(n int) *export.Package {
+		dropped := map[*export.Symbol]bool{}
+		for _, sym := range syms[:n] {
+			dropped[sym] = true
+		}
+		p := *pkg
+		p.Files = nil
+		for _, f := range pkg.Files {
+			p.Files = append(p.Files, &export.File{Name: f.Name, Symbols: append([]*export.Symbol(nil), f.Symbols...)})
+		}
+		p.Filter(func(sym *export.Symbol) bool { return !dropped[sym] })
+		return &p
+	}
+	n := sort.Search(len(syms), func(n int) bool { return packageTokens(without(n), header) <= budget })
+	steps = append(steps, fmt.Sprintf("dropped %d of %d symbols", n, len(syms)))
+	return without(n)
+}
+
+func dropDocs(pkg *export.Package) {
+	pkg.Doc = ""
+	for _, sym := range pkg.Symbols() {
+		sym.Doc = ""
+	}
+}
+
+// dropValues cuts the values off var and const declarations.
+func dropValues(pkg *export.Package) {
+	for _, sym := range pkg.Symbols() {
+		if sym.Kind != export.KindVar && sym.Kind != export.KindConst {
+			continue
+		}
+		if sig, _, ok := strings.Cut(sym.Signature, " = "); ok {
+			sym.Signature = sig
+		}
+	}
+}
+
+// packageStats is a line of the --stats-tokens report.
+type packageStats struct {
+	Path    string `json:"path"`
+	Version string `json:"version,omitempty"`
+	Symbols int    `json:"symbols"`
+	Tokens  int    `json:"tokens"`
+}
+
+// tokenStats collects the --stats-tokens report as packages are listed.
+var tokenStats []packageStats
+
+// addTokenStats records the size of pkg as it would have been printed.
+func addTokenStats(pkg *export.Package, header bool) {
+	tokenStats = append(tokenStats, packageStats{
+		Path:    pkg.Path,
+		Version: pkg.Version,
+		Symbols: len(pkg.Symbols()),
+		Tokens:  packageTokens(pkg, header),
+	})
+}
+
+// printTokenStats prints the --stats-tokens report, a line per package
+// followed by the total, as a table or with --format json as an array.
+func printTokenStats() {
+	if *outputFormat == "json" {
+		encodeJSON(tokenStats)
+		return
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "SYMBOLS\tTOKENS\t PACKAGE")
+	symbols, tokens := 0, 0
+	for _, st := range tokenStats {
+		path := st.Path
+		if st.Version != "" {
+			path += "@" + st.Version
+		}
+		fmt.Fprintf(w, "%d\t%d\t %s\n", st.Symbols, st.Tokens, path)
+		symbols += st.Symbols
+		tokens += st.Tokens
+	}
+	fmt.Fprintf(w, "%d\t%d\t total\n", symbols, tokens)
+	w.Flush()
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github/urie96/go-list-export/pkg/export"
+)
+
+var fitTokensFiles = map[string]string{
+	"go.mod": "module example.com/ft\n\ngo 1.18\n",
+	"p/a.go": `package p
+
+// Big is a struct with many fields.
+type Big struct {
+	Alpha string
+	Beta  int
+	Gamma, Delta, Epsilon, Zeta, Eta, Theta, Iota, Kappa float64
+}
+
+// A does something with a long parameter list.
+func A(name string, count int, weights []float64, done chan struct{}) error { return nil }
+`,
+	"p/b.go": `package p
+
+// B is in another file.
+func B(first, second, third, fourth string) (result string, err error) { return }
+
+// Limit is a constant.
+const Limit = 100
+`,
+}
+
+func TestFitTokens(t *testing.T) {
+	dir := t.TempDir()
+	for name, data := range fitTokensFiles {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	defer func(n int) { *maxTokens = n }(*maxTokens)
+
+	tests := []struct {
+		name   string
+		arg    string   // package or symbol argument
+		files  []string // file arguments, if arg is empty
+		budget int
+		want   []string
+	}{
+		{
+			name:   "package within budget",
+			arg:    "./p",
+			budget: 1000,
+			want: []string{
+				"type Big struct {\n\tAlpha string\n\tBeta int\n\tGamma, Delta, Epsilon, Zeta, Eta, Theta, Iota, Kappa float64\n}",
+				"func A(name string, count int, weights []float64, done chan struct{}) error",
+				"func B(first, second, third, fourth string) (result string, err error)",
+				"const Limit = 100",
+			},
+		},
+		{
+			name:   "symbol collapsed",
+			arg:    "./p.Big",
+			budget: 25,
+			want:   []string{"type Big struct{}"},
+		},
+		{
+			name:   "symbol without parameter names",
+			arg:    "./p.B",
+			budget: 20,
+			want:   []string{"func B(string, string, string, string) (string, error)"},
+		},
+		{
+			name:   "file collapsed",
+			files:  []string{"p/a.go"},
+			budget: 40,
+			want:   []string{"type Big struct{}", "func A(string, int, []float64, chan struct{}) error"},
+		},
+		{
+			name:   "file with dropped symbols",
+			files:  []string{"p/a.go"},
+			budget: 15,
+			want:   []string{"type Big struct{}"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			*maxTokens = tt.budget
+			cfg := newConfig()
+			var pkg *export.Package
+			var err error
+			if tt.arg != "" {
+				pkg, err = loadPackage(cfg, tt.arg, dir)
+			} else {
+				pkg, err = loadFiles(cfg, tt.files, dir)
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			preparePackage(pkg)
+			arrangePackage(pkg)
+			got := []string{}
+			for _, sym := range fitTokens(pkg, false).Symbols() {
+				got = append(got, sym.Signature)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("fitTokens() symbols = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github/urie96/go-list-export/pkg/export"
+)
+
+// treeStack holds the import path segments of the nodes printed by
+// writeTree that the next package may be nested in, outermost first.
+var treeStack [][]string
+
+// writeTree writes pkg as a node of a tree of import paths, indented two
+// spaces per level below the closest package or directory printed before
+// it, with its symbol count. Packages are expected in the order patterns
+// list them, parents before children; a package outside the tree printed
+// so far starts a new one with its full path.
+func writeTree(w io.Writer, pkg *export.Package, header bool) {
+	segs := strings.Split(pkg.Path, "/")
+	for len(treeStack) > 0 && !hasSegPrefix(segs, treeStack[len(treeStack)-1]) {
+		treeStack = treeStack[:len(treeStack)-1]
+	}
+	label := func(name string, last bool) string {
+		if !last {
+			return name + "/"
+		}
+		n := len(pkg.Symbols())
+		if n == 1 {
+			return name + " (1 symbol)"
+		}
+		return fmt.Sprintf("%s (%d symbols)", name, n)
+	}
+	if len(treeStack) == 0 {
+		fmt.Fprintln(w, label(pkg.Path, true))
+		treeStack = append(treeStack, segs)
+		return
+	}
+	parent := treeStack[len(treeStack)-1]
+	rest := segs[len(parent):]
+	for i, seg := range rest {
+		fmt.Fprintf(w, "%s%s\n", strings.Repeat("  ", len(treeStack)), label(seg, i == len(rest)-1))
+		treeStack = append(treeStack, segs[:len(parent)+i+1])
+	}
+}
+
+// hasSegPrefix reports whether the path segments prefix start segs, and
+// segs is longer.
+func hasSegPrefix(segs, prefix []string) bool {
+	if len(segs) <= len(prefix) {
+		return false
+	}
+	for i, seg := range prefix {
+		if segs[i] != seg {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+	"go/token"
+	"os"
+	"path/filepath"
+
+	"github/urie96/go-list-export/pkg/export"
+)
+
+// unusedExport is an exported symbol that no other package of the module
+// or workspace refers to.
+type unusedExport struct {
+	Path string      `json:"path"`
+	Key  string      `json:"key"`
+	Kind export.Kind `json:"kind"`
+}
+
+// modulePackage is a package of the modules the unused-exports command
+// looks at.
+type modulePackage struct {
+	path, dir string
+}
+
+// runUnusedExports prints the exported functions, types, variables and
+// constants of the packages of the current module, or of every module of
+// the go.work workspace enclosing it, that no other of those packages
+// refers to: candidates for unexporting. Methods are left out, as they may
+// be needed to satisfy interfaces, and so are main packages. References
+// from test files do not count.
+func runUnusedExports(cwd string, args []string) {
+	if len(args) > 0 {
+		usageError("unused-exports: usage: unused-exports")
+	}
+	var roots []string
+	if work := findWorkFile(cwd); work != "" && os.Getenv("GOWORK") != "off" {
+		roots = workspaceModules(work)
+	} else if root := moduleRoot(cwd); root != "" {
+		roots = []string{root}
+	} else {
+		fatal(fmt.Errorf("%s is not in a module", cwd))
+	}
+	pkgs := []modulePackage{}
+	for _, root := range roots {
+		modPath := modulePath(filepath.Join(root, "go.mod"))
+		rels, err := walkPackageDirs(root, -1)
+		if err != nil {
+			report(err)
+		}
+		for _, rel := range rels {
+			pkgs = append(pkgs, modulePackage{joinImportPath(modPath, rel), filepath.Join(root, filepath.FromSlash(rel))})
+		}
+	}
+
+	fset := token.NewFileSet()
+	imp := newTypesQuery(cwd).imp
+	referenced := map[string]bool{}
+	for _, p := range pkgs {
+		tpkg, uses, err := checkDir(fset, imp, p.dir)
+		if err != nil {
+			report(err)
+			continue
+		}
+		for _, obj := range uses {
+			if obj.Pkg() == nil || obj.Pkg() == tpkg || !obj.Exported() {
+				continue
+			}
+			if key, _ := usageKey(obj, nil); key != "" {
+				referenced[obj.Pkg().Path()+"."+key] = true
+			}
+		}
+	}
+
+	cfg := newConfig()
+	found := []unusedExport{}
+	for _, p := range pkgs {
+		pkg, err := cfg.ParseDir(p.dir)
+		if pkg == nil {
+			report(err)
+			continue
+		}
+		if pkg.Name == "" || pkg.Name == "main" {
+			continue
+		}
+		filterPackage(pkg)
+		pkg.Sort(sortOrder)
+		for _, sym := range pkg.Symbols() {
+			if sym.Kind != export.KindMethod && !referenced[p.path+"."+sym.Key()] {
+				found = append(found, unusedExport{Path: p.path, Key: sym.Key(), Kind: sym.Kind})
+			}
+		}
+	}
+
+	if *outputFormat == "json" {
+		encodeJSON(found)
+		return
+	}
+	for _, u := range found {
+		fmt.Printf("%s.%s (%s)\n", u.Path, u.Key, u.Kind)
+	}
+}
@@ -0,0 +1,231 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// symbolUsage is an exported symbol of the dependency that the uses
+// command found referenced, with the positions of the references.
+type symbolUsage struct {
+	Path       string   `json:"path"`
+	Key        string   `json:"key"`
+	Kind       string   `json:"kind"`
+	References []string `json:"references"`
+
+	positions []token.Position
+}
+
+// runUsage prints the exported symbols of a dependency, or of the packages
+// matched when it is a pattern, that the given packages of the project
+// reference: functions, types, variables and constants, and the methods
+// and fields of the dependency's types. The packages default to ./...,
+// internal ones included. Test files are not looked at.
+func runUses(cwd string, args []string) {
+	if len(args) == 0 {
+		usageError("uses: usage: uses dependency [packages...]")
+	}
+	dep := args[0]
+	matches := func(path string) bool { return path == dep }
+	if isPattern(dep) {
+		root := patternRoot(dep)
+		matches = func(path string) bool { return path == root || strings.HasPrefix(path, root+"/") }
+	}
+	patterns := args[1:]
+	if len(patterns) == 0 {
+		patterns = []string{"./..."}
+	}
+
+	fset := token.NewFileSet()
+	imp := newTypesQuery(cwd).imp
+	fields := map[*types.Package]map[*types.Var]string{}
+	found := map[string]*symbolUsage{}
+	for _, dir := range projectDirs(patterns, cwd) {
+		_, uses, err := checkDir(fset, imp, dir)
+		if err != nil {
+			report(err)
+			continue
+		}
+		for ident, obj := range uses {
+			if obj.Pkg() == nil || !obj.Exported() || !matches(obj.Pkg().Path()) {
+				continue
+			}
+			if fields[obj.Pkg()] == nil {
+				fields[obj.Pkg()] = structFields(obj.Pkg())
+			}
+			key, kind := usageKey(obj, fields[obj.Pkg()])
+			if key == "" {
+				continue
+			}
+			id := obj.Pkg().Path() + "." + key
+			if found[id] == nil {
+				found[id] = &symbolUsage{Path: obj.Pkg().Path(), Key: key, Kind: kind}
+			}
+			found[id].positions = append(found[id].positions, fset.Position(ident.Pos()))
+		}
+	}
+
+	list := []*symbolUsage{}
+	for _, u := range found {
+		sort.Slice(u.positions, func(i, j int) bool {
+			a, b := u.positions[i], u.positions[j]
+			if a.Filename != b.Filename {
+				return a.Filename < b.Filename
+			}
+			return a.Offset < b.Offset
+		})
+		for _, pos := range u.positions {
+			if rel, err := filepath.Rel(cwd, pos.Filename); err == nil && !strings.HasPrefix(rel, "..") {
+				pos.Filename = rel
+			}
+			u.References = append(u.References, pos.String())
+		}
+		list = append(list, u)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Path != list[j].Path {
+			return list[i].Path < list[j].Path
+		}
+		return list[i].Key < list[j].Key
+	})
+	if *outputFormat == "json" {
+		encodeJSON(list)
+		return
+	}
+	for _, u := range list {
+		fmt.Printf("%s.%s (%s, %d)\n", u.Path, u.Key, u.Kind, len(u.References))
+		for _, ref := range u.References {
+			fmt.Printf("\t%s\n", ref)
+		}
+	}
+}
+
+// projectDirs returns the directories of the packages matched by the
+// patterns. Unlike expandArgs it keeps internal packages, which are as
+// much part of the project as the others.
+func projectDirs(patterns []string, cwd string) []string {
+	dirs := []string{}
+	for _, arg := range patterns {
+		root := arg
+		if isPattern(arg) {
+			root = patternRoot(arg)
+		}
+		dir, err := resolveOrDownloadDir(root, cwd)
+		if err != nil {
+			report(err)
+			continue
+		}
+		if !isPattern(arg) {
+			dirs = append(dirs, dir)
+			continue
+		}
+		rels, err := walkPackageDirs(dir, *maxDepth)
+		if err != nil {
+			report(err)
+		}
+		for _, rel := range dropExcluded(root, rels) {
+			dirs = append(dirs, filepath.Join(dir, filepath.FromSlash(rel)))
+		}
+	}
+	return dirs
+}
+
+// parsePackageFiles parses the non-test Go files of dir that match the
+// default build context.
+func parsePackageFiles(fset *token.FileSet, dir string) ([]*ast.File, error) {
+	bp, err := build.ImportDir(dir, 0)
+	if err != nil {
+		if _, ok := err.(*build.NoGoError); ok {
+			return nil, nil
+		}
+		return nil, err
+	}
+	files := []*ast.File{}
+	for _, name := range append(bp.GoFiles, bp.CgoFiles...) {
+		f, err := parser.ParseFile(fset, filepath.Join(dir, name), nil, parser.SkipObjectResolution)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, f)
+	}
+	return files, nil
+}
+
+// checkDir type-checks the package in dir, importing its dependencies
+// with imp, and returns it with the objects its identifiers refer to. Both
+// are nil when dir has no Go files. Type errors are ignored.
+func checkDir(fset *token.FileSet, imp types.Importer, dir string) (*types.Package, map[*ast.Ident]types.Object, error) {
+	files, err := parsePackageFiles(fset, dir)
+	if err != nil || len(files) == 0 {
+		return nil, nil, err
+	}
+	info := &types.Info{Uses: map[*ast.Ident]types.Object{}}
+	conf := types.Config{Importer: imp, Error: func(err error) {}, FakeImportC: true}
+	pkg, _ := conf.Check(files[0].Name.Name, fset, files, info)
+	return pkg, info.Uses, nil
+}
+
+// structFields maps the fields of the package-level struct types of pkg,
+// promoted ones being the same objects, to their "Type.Field" keys.
+func structFields(pkg *types.Package) map[*types.Var]string {
+	fields := map[*types.Var]string{}
+	scope := pkg.Scope()
+	for _, name := range scope.Names() {
+		tn, ok := scope.Lookup(name).(*types.TypeName)
+		if !ok || tn.IsAlias() {
+			continue
+		}
+		if st, ok := tn.Type().Underlying().(*types.Struct); ok {
+			for i := 0; i < st.NumFields(); i++ {
+				fields[st.Field(i)] = name + "." + st.Field(i).Name()
+			}
+		}
+	}
+	return fields
+}
+
+// usageKey returns the key of the symbol obj refers to, as Symbol.Key
+// would, and its kind. The key is "" for objects that are not part of the
+// package's API, such as the fields of anonymous structs.
+func usageKey(obj types.Object, fields map[*types.Var]string) (key, kind string) {
+	switch obj := obj.(type) {
+	case *types.Func:
+		fn := obj.Origin()
+		recv := fn.Type().(*types.Signature).Recv()
+		if recv == nil {
+			return fn.Name(), "func"
+		}
+		t := recv.Type()
+		if p, ok := t.(*types.Pointer); ok {
+			t = p.Elem()
+		}
+		named, ok := t.(*types.Named)
+		if !ok {
+			return "", ""
+		}
+		return named.Obj().Name() + "." + fn.Name(), "method"
+	case *types.Var:
+		if obj.IsField() {
+			return fields[obj.Origin()], "field"
+		}
+		if obj.Parent() == obj.Pkg().Scope() {
+			return obj.Name(), "var"
+		}
+	case *types.Const:
+		if obj.Parent() == obj.Pkg().Scope() {
+			return obj.Name(), "const"
+		}
+	case *types.TypeName:
+		if obj.Parent() == obj.Pkg().Scope() {
+			return obj.Name(), "type"
+		}
+	}
+	return "", ""
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"fmt"
+	"go/types"
+)
+
+// typeUse is a function or method found by the uses-type command. Accepts
+// and Returns tell whether the type appears in its parameters or results.
+type typeUse struct {
+	Path      string `json:"path"`
+	Name      string `json:"name"`
+	Receiver  string `json:"receiver,omitempty"`
+	Signature string `json:"signature"`
+	Accepts   bool   `json:"accepts,omitempty"`
+	Returns   bool   `json:"returns,omitempty"`
+}
+
+// runUsesType prints every exported function and method of the packages
+// given after the type argument whose parameters or results mention the
+// type, be it directly, through a pointer, slice, map, channel or function
+// type, or as a type argument. The receiver of a method does not count.
+func runUsesType(cwd string, args []string) {
+	if len(args) < 2 {
+		usageError("uses-type: usage: uses-type pkg.Type packages...")
+	}
+	q := newTypesQuery(cwd)
+	tn, err := q.lookupType(args[0])
+	if err != nil {
+		fatal(err)
+	}
+
+	found := []typeUse{}
+	for _, path := range expandArgs(args[1:], cwd) {
+		pkg, err := loadPackage(q.cfg, path, cwd)
+		if pkg == nil {
+			report(err)
+			continue
+		}
+		if pkg.Types() == nil { // no Go files
+			continue
+		}
+		target := tn
+		if path == tn.Pkg().Path() {
+			// The package was parsed rather than imported, so its
+			// declaration of the type is a distinct object.
+			if local, ok := pkg.Types().Scope().Lookup(tn.Name()).(*types.TypeName); ok {
+				target = local
+			}
+		}
+		qual := types.RelativeTo(pkg.Types())
+		check := func(fn *types.Func, recv string) {
+			sig := fn.Type().(*types.Signature)
+			use := typeUse{
+				Path:      path,
+				Name:      fn.Name(),
+				Receiver:  recv,
+				Signature: types.ObjectString(fn, qual),
+				Accepts:   mentionsType(sig.Params(), target),
+				Returns:   mentionsType(sig.Results(), target),
+			}
+			if use.Accepts || use.Returns {
+				found = append(found, use)
+			}
+		}
+		scope := pkg.Types().Scope()
+		for _, name := range scope.Names() {
+			switch obj := scope.Lookup(name).(type) {
+			case *types.Func:
+				if obj.Exported() {
+					check(obj, "")
+				}
+			case *types.TypeName:
+				if !obj.Exported() || obj.IsAlias() {
+					continue
+				}
+				named, ok := obj.Type().(*types.Named)
+				if !ok {
+					continue
+				}
+				if iface, ok := named.Underlying().(*types.Interface); ok {
+					for i := 0; i < iface.NumMethods(); i++ {
+						if m := iface.Method(i); m.Exported() {
+							check(m, name)
+						}
+					}
+					continue
+				}
+				for i := 0; i < named.NumMethods(); i++ {
+					if m := named.Method(i); m.Exported() {
+						check(m, name)
+					}
+				}
+			}
+		}
+	}
+
+	if *outputFormat == "json" {
+		encodeJSON(found)
+		return
+	}
+	for _, use := range found {
+		fmt.Printf("%s: %s\n", use.Path, use.Signature)
+	}
+}
+
+// mentionsType reports whether t refers to the type declared by target,
+// or to an instance of it when it is generic.
+func mentionsType(t types.Type, target *types.TypeName) bool {
+	switch t := t.(type) {
+	case *types.Named:
+		if t.Obj() == target || t.Origin().Obj() == target {
+			return true
+		}
+		for i := 0; i < t.TypeArgs().Len(); i++ {
+			if mentionsType(t.TypeArgs().At(i), target) {
+				return true
+			}
+		}
+	case *types.Alias:
+		return t.Obj() == target || mentionsType(types.Unalias(t), target)
+	case *types.Pointer:
+		return mentionsType(t.Elem(), target)
+	case *types.Slice:
+		return mentionsType(t.Elem(), target)
+	case *types.Array:
+		return mentionsType(t.Elem(), target)
+	case *types.Chan:
+		return mentionsType(t.Elem(), target)
+	case *types.Map:
+		return mentionsType(t.Key(), target) || mentionsType(t.Elem(), target)
+	case *types.Signature:
+		return mentionsType(t.Params(), target) || mentionsType(t.Results(), target)
+	case *types.Tuple:
+		for i := 0; i < t.Len(); i++ {
+			if mentionsType(t.At(i).Type(), target) {
+				return true
+			}
+		}
+	case *types.Struct:
+		for i := 0; i < t.NumFields(); i++ {
+			if mentionsType(t.Field(i).Type(), target) {
+				return true
+			}
+		}
+	}
+	return false
+}
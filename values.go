@@ -0,0 +1,38 @@
+package main
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github/urie96/go-list-export/pkg/export"
+)
+
+// limitValues applies --no-values and --max-value-len to the values of
+// var and const declarations. Values longer than --max-value-len runes,
+// or spanning several lines as raw strings can, are cut and end in "...".
+func limitValues(pkg *export.Package) {
+	if *noValues {
+		dropValues(pkg)
+		return
+	}
+	if *maxValueLen <= 0 {
+		return
+	}
+	for _, sym := range pkg.Symbols() {
+		if sym.Kind != export.KindVar && sym.Kind != export.KindConst {
+			continue
+		}
+		decl, value, ok := strings.Cut(sym.Signature, " = ")
+		if !ok {
+			continue
+		}
+		line, _, multiline := strings.Cut(value, "\n")
+		if !multiline && utf8.RuneCountInString(value) <= *maxValueLen {
+			continue
+		}
+		if utf8.RuneCountInString(line) > *maxValueLen {
+			line = string([]rune(line)[:*maxValueLen])
+		}
+		sym.Signature = decl + " = " + line + "..."
+	}
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// vendorDir returns the directory of importPath in the vendor directory of
+// the module enclosing cwd, or "" if that module does not vendor it. Only
+// packages listed in vendor/modules.txt count, as for the go command, so
+// that stale directories are ignored.
+func vendorDir(importPath, cwd string) string {
+	root := moduleRoot(cwd)
+	if root == "" {
+		return ""
+	}
+	vendor := filepath.Join(root, "vendor")
+	if _, ok := vendorPackages(vendor)[importPath]; !ok {
+		return ""
+	}
+	dir := filepath.Join(vendor, filepath.FromSlash(importPath))
+	if fi, err := os.Stat(dir); err != nil || !fi.IsDir() {
+		return ""
+	}
+	return dir
+}
+
+// vendoredModule is a module listed in vendor/modules.txt.
+type vendoredModule struct {
+	Path, Version string
+}
+
+// vendorModule returns the module a vendored package directory comes
+// from, as recorded in vendor/modules.txt, or nil.
+func vendorModule(dir string) *vendoredModule {
+	for vendor := filepath.Dir(dir); vendor != filepath.Dir(vendor); vendor = filepath.Dir(vendor) {
+		if filepath.Base(vendor) != "vendor" {
+			continue
+		}
+		rel, err := filepath.Rel(vendor, dir)
+		if err != nil {
+			return nil
+		}
+		return vendorPackages(vendor)[filepath.ToSlash(rel)]
+	}
+	return nil
+}
+
+// vendorPackages reads vendor/modules.txt, mapping every vendored package
+// to its module. The version of a module replaced by a directory is
+// empty.
+func vendorPackages(vendor string) map[string]*vendoredModule {
+	f, err := os.Open(filepath.Join(vendor, "modules.txt"))
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+	pkgs := map[string]*vendoredModule{}
+	var mod *vendoredModule
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := sc.Text()
+		switch {
+		case strings.HasPrefix(line, "# "):
+			// "# module version" or "# module [version] => replacement [version]"
+			fields := strings.Fields(line)
+			mod = &vendoredModule{Path: fields[1]}
+			if len(fields) >= 3 && fields[2] != "=>" {
+				mod.Version = fields[2]
+			}
+			if i := strings.Index(line, "=>"); i >= 0 {
+				repl := strings.Fields(line[i+2:])
+				mod.Version = ""
+				if len(repl) == 2 {
+					mod.Version = repl[1]
+				}
+			}
+		case line != "" && !strings.HasPrefix(line, "#") && mod != nil:
+			pkgs[line] = mod
+		}
+	}
+	return pkgs
+}
+
+// moduleRoot returns the directory of the go.mod file enclosing dir, or ""
+// if there is none.
+func moduleRoot(dir string) string {
+	for {
+		if _, err := os.Stat(filepath.Join(dir, "go.mod")); err == nil {
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
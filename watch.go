@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github/urie96/go-list-export/pkg/export"
+)
+
+// watchInterval is how often --watch polls the watched directories. Polling
+// keeps the tool free of platform-specific file notification code.
+const watchInterval = time.Second
+
+// watchTarget is a directory whose Go files --watch monitors, including
+// those of its subdirectories if recursive is set.
+type watchTarget struct {
+	dir       string
+	recursive bool
+}
+
+// fileState is what --watch compares to detect a changed file.
+type fileState struct {
+	size    int64
+	modTime time.Time
+}
+
+// watchLoop lists args, then polls the directories read for changes to Go
+// files and lists args again after every change, until interrupted.
+func watchLoop(cfg *export.Config, cwd string, args []string) {
+	for {
+		targets := listArgs(cfg, cwd, args)
+		snap := snapshot(targets)
+		for {
+			time.Sleep(watchInterval)
+			if next := snapshot(targets); !sameSnapshot(snap, next) {
+				break
+			}
+		}
+		fmt.Fprintf(os.Stderr, "// change detected at %s, listing again\n", time.Now().Format(time.TimeOnly))
+		if *outputFormat == "text" {
+			fmt.Printf("// ---- %s ----\n\n", time.Now().Format(time.TimeOnly))
+		}
+	}
+}
+
+// snapshot records the size and modification time of every Go file in
+// the watched directories. Directories that cannot be read, such as the
+// inside of a module zip, are skipped.
+func snapshot(targets []watchTarget) map[string]fileState {
+	snap := map[string]fileState{}
+	add := func(path string, d fs.DirEntry) {
+		if d.IsDir() || !strings.HasSuffix(d.Name(), ".go") {
+			return
+		}
+		if info, err := d.Info(); err == nil {
+			snap[path] = fileState{info.Size(), info.ModTime()}
+		}
+	}
+	for _, t := range targets {
+		if t.recursive {
+			filepath.WalkDir(t.dir, func(path string, d fs.DirEntry, err error) error {
+				if err == nil {
+					add(path, d)
+				}
+				return nil
+			})
+			continue
+		}
+		list, err := os.ReadDir(t.dir)
+		if err != nil {
+			continue
+		}
+		for _, d := range list {
+			add(filepath.Join(t.dir, d.Name()), d)
+		}
+	}
+	return snap
+}
+
+func sameSnapshot(a, b map[string]fileState) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for path, sa := range a {
+		if sb, ok := b[path]; !ok || sa.size != sb.size || !sa.modTime.Equal(sb.modTime) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// workspaceDir returns the directory of importPath in the modules of the
+// go.work workspace enclosing cwd, or "" if no module of the workspace
+// provides it. The module with the longest matching path wins, so the
+// package is listed at its working-tree state like the go command builds
+// it. GOWORK selects the go.work file, or disables workspaces when "off".
+func workspaceDir(importPath, cwd string) string {
+	work := os.Getenv("GOWORK")
+	switch work {
+	case "off":
+		return ""
+	case "":
+		work = findWorkFile(cwd)
+		if work == "" {
+			return ""
+		}
+	}
+	best, bestDir := "", ""
+	for _, dir := range workspaceModules(work) {
+		modPath := modulePath(filepath.Join(dir, "go.mod"))
+		if modPath == "" || len(modPath) <= len(best) {
+			continue
+		}
+		if rel, ok := strings.CutPrefix(importPath, modPath); ok && (rel == "" || rel[0] == '/') {
+			best, bestDir = modPath, filepath.Join(dir, filepath.FromSlash(rel))
+		}
+	}
+	if bestDir == "" {
+		return ""
+	}
+	if fi, err := os.Stat(bestDir); err != nil || !fi.IsDir() {
+		return ""
+	}
+	return bestDir
+}
+
+// findWorkFile returns the go.work file in dir or its closest parent, or
+// "" if there is none.
+func findWorkFile(dir string) string {
+	for {
+		file := filepath.Join(dir, "go.work")
+		if _, err := os.Stat(file); err == nil {
+			return file
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// workspaceModules returns the module directories named by the use
+// directives of a go.work file, in both their single-line and block
+// forms.
+func workspaceModules(work string) []string {
+	f, err := os.Open(work)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+	dirs := []string{}
+	inUse := false
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := sc.Text()
+		if i := strings.Index(line, "//"); i >= 0 {
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+		switch {
+		case len(fields) == 0:
+			continue
+		case inUse && fields[0] == ")":
+			inUse = false
+			continue
+		case fields[0] == "use" && len(fields) > 1 && fields[1] == "(":
+			inUse = true
+			continue
+		case fields[0] == "use" && len(fields) > 1:
+			fields = fields[1:]
+		case !inUse:
+			continue
+		}
+		dir := unquoteModField(fields[0])
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(filepath.Dir(work), dir)
+		}
+		dirs = append(dirs, dir)
+	}
+	return dirs
+}
+
+// modulePath returns the module path declared by a go.mod file, or "".
+func modulePath(gomod string) string {
+	data, err := os.ReadFile(gomod)
+	if err != nil {
+		return ""
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) >= 2 && fields[0] == "module" {
+			return unquoteModField(fields[1])
+		}
+	}
+	return ""
+}
+
+// unquoteModField removes the quotes go.mod and go.work allow around a
+// path.
+func unquoteModField(s string) string {
+	if u, err := strconv.Unquote(s); err == nil {
+		return u
+	}
+	return s
+}
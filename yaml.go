@@ -0,0 +1,154 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github/urie96/go-list-export/pkg/export"
+)
+
+// writeYAML writes pkg as one YAML document. The document mirrors the JSON
+// model: keys and omitempty handling come from the json struct tags and
+// appear in struct field order, so the output is stable and diffable.
+func writeYAML(w io.Writer, pkg *export.Package, header bool) {
+	fmt.Fprintln(w, "---")
+	inline, lines := yamlValue(reflect.ValueOf(pkg))
+	if inline != "" {
+		fmt.Fprintln(w, inline)
+	}
+	for _, line := range lines {
+		fmt.Fprintln(w, line)
+	}
+}
+
+// yamlValue renders v either as an inline scalar (inline), as block lines
+// to be indented under their parent (lines), or both for block scalars,
+// where inline holds the "|-" indicator.
+func yamlValue(v reflect.Value) (inline string, lines []string) {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return "null", nil
+		}
+		v = v.Elem()
+	}
+	switch v.Kind() {
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			name, opts, _ := strings.Cut(field.Tag.Get("json"), ",")
+			if name == "-" {
+				continue
+			}
+			if name == "" {
+				name = field.Name
+			}
+			fv := v.Field(i)
+			if strings.Contains(opts, "omitempty") && isEmptyValue(fv) {
+				continue
+			}
+			lines = append(lines, yamlEntry(yamlString(name)+":", fv)...)
+		}
+		if len(lines) == 0 {
+			return "{}", nil
+		}
+		return "", lines
+	case reflect.Map:
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool {
+			return fmt.Sprint(keys[i]) < fmt.Sprint(keys[j])
+		})
+		for _, key := range keys {
+			lines = append(lines, yamlEntry(yamlString(fmt.Sprint(key))+":", v.MapIndex(key))...)
+		}
+		if len(lines) == 0 {
+			return "{}", nil
+		}
+		return "", lines
+	case reflect.Slice, reflect.Array:
+		if v.Len() == 0 {
+			return "[]", nil
+		}
+		for i := 0; i < v.Len(); i++ {
+			item := yamlEntry("-", v.Index(i))
+			if item[0] == "-" && len(item) > 1 {
+				// a nested mapping or sequence starts next to the dash
+				item = append([]string{"- " + strings.TrimPrefix(item[1], "  ")}, item[2:]...)
+			}
+			lines = append(lines, item...)
+		}
+		return "", lines
+	case reflect.String:
+		s := v.String()
+		if strings.Contains(s, "\n") && !strings.HasPrefix(s, " ") && !strings.Contains(s, "\n ") {
+			return "|-", strings.Split(s, "\n")
+		}
+		return yamlString(s), nil
+	default:
+		return fmt.Sprint(v.Interface()), nil
+	}
+}
+
+// yamlEntry renders a "key:" or "-" prefix followed by v, indenting block
+// content by two spaces.
+func yamlEntry(prefix string, v reflect.Value) []string {
+	inline, lines := yamlValue(v)
+	res := []string{prefix}
+	if inline != "" {
+		res[0] += " " + inline
+	}
+	for _, line := range lines {
+		if line == "" {
+			res = append(res, "")
+		} else {
+			res = append(res, "  "+line)
+		}
+	}
+	return res
+}
+
+// yamlString returns s as a plain scalar when that is unambiguous and as a
+// double-quoted scalar otherwise.
+func yamlString(s string) string {
+	switch strings.ToLower(s) {
+	case "", "~", "null", "true", "false", "yes", "no", "on", "off", "y", "n":
+		return strconv.Quote(s)
+	}
+	if _, err := strconv.ParseFloat(s, 64); err == nil {
+		return strconv.Quote(s)
+	}
+	if strings.ContainsAny(s[:1], "-?:,[]{}#&*!|>'\"%@` \t") ||
+		strings.Contains(s, ": ") || strings.Contains(s, " #") ||
+		strings.HasSuffix(s, ":") || strings.HasSuffix(s, " ") ||
+		strings.ContainsAny(s, "\n\t\r") {
+		return strconv.Quote(s)
+	}
+	return s
+}
+
+// isEmptyValue mirrors encoding/json's notion of an empty value for the
+// omitempty option.
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Pointer:
+		return v.IsNil()
+	}
+	return false
+}
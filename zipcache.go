@@ -0,0 +1,63 @@
+package main
+
+import (
+	"archive/zip"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github/urie96/go-list-export/pkg/export"
+)
+
+// cachedModuleZip finds the zip of the module holding importPath in
+// GOMODCACHE's download cache (cache/download/<module>/@v/<version>.zip).
+// With an empty version the preferred cached version is used. It returns
+// the zip file, module path and version, or empty strings if none exists.
+func cachedModuleZip(importPath, version string) (zipPath, modPath, modVersion string) {
+	for modPath := importPath; modPath != "." && modPath != "/"; modPath = path.Dir(modPath) {
+		vdir := filepath.Join(goModCache(), "cache", "download", escapeModulePath(modPath), "@v")
+		if version != "" {
+			zipPath := filepath.Join(vdir, escapeModulePath(version)+".zip")
+			if _, err := os.Stat(zipPath); err == nil {
+				return zipPath, modPath, version
+			}
+			continue
+		}
+		list, err := os.ReadDir(vdir)
+		if err != nil {
+			continue
+		}
+		versions := []string{}
+		for _, f := range list {
+			if v, ok := strings.CutSuffix(f.Name(), ".zip"); ok && !f.IsDir() {
+				versions = append(versions, unescapeModulePath(v))
+			}
+		}
+		if len(versions) == 0 {
+			continue
+		}
+		sortVersionsPreferred(versions)
+		return filepath.Join(vdir, escapeModulePath(versions[0])+".zip"), modPath, versions[0]
+	}
+	return "", "", ""
+}
+
+// loadFromCacheZip parses arg straight from a module zip in the download
+// cache, without extracting it. It returns nil and no error if there is
+// no such zip.
+func loadFromCacheZip(cfg *export.Config, arg string) (*export.Package, error) {
+	importPath, version := splitVersion(arg)
+	zipPath, modPath, modVersion := cachedModuleZip(importPath, version)
+	if zipPath == "" {
+		return nil, nil
+	}
+	r, err := zip.OpenReader(zipPath)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	fmt.Fprintf(os.Stderr, "// reading %s@%s from %s\n", modPath, modVersion, zipPath)
+	return parseModuleZip(cfg, &r.Reader, arg, modPath, modVersion)
+}